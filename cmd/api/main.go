@@ -1,30 +1,58 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerfiles "github.com/swaggo/files"
 	ginswagger "github.com/swaggo/gin-swagger"
 
 	"github.com/fiap-161/tc-golunch-operation-service/database"
 	_ "github.com/fiap-161/tc-golunch-operation-service/docs"
 
+	admincontroller "github.com/fiap-161/tc-golunch-operation-service/internal/admin/controller"
+	admindto "github.com/fiap-161/tc-golunch-operation-service/internal/admin/dto"
+	admindatasource "github.com/fiap-161/tc-golunch-operation-service/internal/admin/external/datasource"
+	admingateway "github.com/fiap-161/tc-golunch-operation-service/internal/admin/gateway"
+	adminhandler "github.com/fiap-161/tc-golunch-operation-service/internal/admin/handler"
 	authcontroller "github.com/fiap-161/tc-golunch-operation-service/internal/auth/controller"
+	authdto "github.com/fiap-161/tc-golunch-operation-service/internal/auth/dto"
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/external"
+	authhandler "github.com/fiap-161/tc-golunch-operation-service/internal/auth/handler"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/http/health"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/http/middleware"
 	ordercontroller "github.com/fiap-161/tc-golunch-operation-service/internal/order/controller"
 	ordermodel "github.com/fiap-161/tc-golunch-operation-service/internal/order/dto"
+	orderentity "github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	orderenum "github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	orderbroadcaster "github.com/fiap-161/tc-golunch-operation-service/internal/order/external/broadcaster"
 	orderdatasource "github.com/fiap-161/tc-golunch-operation-service/internal/order/external/datasource"
+	ordermetrics "github.com/fiap-161/tc-golunch-operation-service/internal/order/external/metrics"
+	orderproductcache "github.com/fiap-161/tc-golunch-operation-service/internal/order/external/productcache"
 	ordergateway "github.com/fiap-161/tc-golunch-operation-service/internal/order/gateway"
 	orderhandler "github.com/fiap-161/tc-golunch-operation-service/internal/order/handler"
 	orderusecases "github.com/fiap-161/tc-golunch-operation-service/internal/order/usecases"
+	orderworker "github.com/fiap-161/tc-golunch-operation-service/internal/order/worker"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/config"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/httpclient"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/logger"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/tracing"
 )
 
+// panelStreamRoute is the kitchen panel event stream, kept out of the request metrics
+const panelStreamRoute = "/admin/orders/panel/stream"
+
 // @title           GoLunch Operation Service API
 // @version         1.0
 // @description     API para gerenciamento das operações da cozinha e painel administrativo da lanchonete GoLunch
@@ -40,77 +68,311 @@ import (
 // @host            localhost:8083
 // @BasePath        /
 func main() {
-	r := gin.Default()
-	loadYAML()
+	cfg, err := config.Load("./conf/environment")
+	if err != nil {
+		slog.Error("failed to load configuration", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
-	db := database.NewPostgresDatabase().GetDb()
+	appLogger := logger.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	slog.SetDefault(appLogger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal(appLogger, "failed to set up tracing", err)
+	}
+
+	r := gin.New()
+	r.Use(middleware.Tracing(), middleware.RequestID(), middleware.RequestLogger(appLogger), gin.Recovery(), middleware.Metrics(panelStreamRoute))
+
+	postgres, err := database.NewPostgresDatabase(cfg.Database)
+	if err != nil {
+		fatal(appLogger, "failed to connect to the database", err)
+	}
+	db := postgres.GetDb()
 
-	if err := db.AutoMigrate(
+	models := []any{
 		&ordermodel.OrderDAO{},
-	); err != nil {
-		log.Fatalf("Erro ao migrar o banco: %v", err)
+		&ordermodel.OrderItemDAO{},
+		&ordermodel.OrderStatusHistoryDAO{},
+		&ordermodel.OutboxEntryDAO{},
+		// The access token denylist is checked in both auth modes
+		&authdto.RefreshTokenDAO{},
+		&authdto.RevokedAccessTokenDAO{},
+	}
+	if cfg.Auth.Mode == config.AuthModeLocal {
+		models = append(models, &admindto.AdminDAO{})
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		fatal(appLogger, "failed to migrate the database", err)
 	}
 
-	// JWT service for generate and validate tokens
-	jwtGateway := external.NewJWTService(os.Getenv("SECRET_KEY"), 24*time.Hour)
-	authController := authcontroller.New(jwtGateway)
+	// JWT service for generate and validate tokens, with the refresh tokens and the denylist in the database
+	signingKeys, err := tokenKeys(cfg.Auth)
+	if err != nil {
+		fatal(appLogger, "failed to load the token signing keys", err)
+	}
+	jwtGateway := external.NewJWTService(signingKeys, cfg.Auth.TokenExpiry, cfg.Auth.Issuer, cfg.Auth.Audience)
+	authController := authcontroller.New(jwtGateway, external.NewSessionStore(db), cfg.Auth.RefreshTokenExpiry)
+	authHandler := authhandler.New(authController)
 
 	// Order Data Source and Gateway
 	orderDataSource := orderdatasource.New(db)
 	orderGateway := ordergateway.Build(orderDataSource)
 
 	// HTTP Clients para outros serviços
-	productClient := httpclient.NewProductClient("http://localhost:8081")
-	productOrderClient := httpclient.NewProductOrderClient("http://localhost:8081")
-	paymentClient := httpclient.NewPaymentClient("http://localhost:8082")
+	// Each downstream service has one client, so its circuit breaker sees every call made to it
+	productService := httpclient.NewClient("product", cfg.Services.Product.URL, clientConfig(cfg.Services.Product), appLogger)
+	paymentService := httpclient.NewClient("payment", cfg.Services.Payment.URL, clientConfig(cfg.Services.Payment), appLogger)
+	productClient := httpclient.NewProductClient(productService)
+	productOrderClient := httpclient.NewProductOrderClient(productService)
+	paymentClient := httpclient.NewPaymentClient(paymentService)
+
+	// Product catalog cache so order creation survives bursts and product service outages
+	productCache := orderproductcache.New(productClient, orderproductcache.DefaultTTL, orderproductcache.DefaultStaleTTL, orderproductcache.DefaultMaxAge, orderproductcache.DefaultMaxEntries)
+
+	// In-process broadcaster feeding the real-time kitchen panel
+	panelBroadcaster := orderbroadcaster.New(orderbroadcaster.DefaultBufferSize)
 
 	// Order Use Case
-	orderUseCase := orderusecases.Build(orderGateway, productClient, productOrderClient, paymentClient)
+	preparationEstimator := orderentity.NewPreparationEstimator(preparationEstimatorConfig(cfg.Kitchen))
+	orderMetrics := ordermetrics.NewRecorder(prometheus.DefaultRegisterer)
+	orderUseCase := orderusecases.Build(orderGateway, productCache, productOrderClient, paymentClient, panelBroadcaster, preparationEstimator, orderMetrics, appLogger)
+	prometheus.MustRegister(ordermetrics.NewStatusCollector(orderUseCase, ordermetrics.DefaultStatusCacheTTL))
+
+	// Background delivery of the order outbox
+	outboxDispatcher := orderworker.NewOutboxDispatcher(orderUseCase, orderworker.DefaultOutboxInterval, orderworker.DefaultOutboxBatchSize, appLogger)
+	outboxDispatcher.Start(context.Background())
 
 	// Order Controller and Handler
 	orderController := ordercontroller.Build(orderUseCase)
-	orderHandler := orderhandler.New(orderController)
+	orderHandler := orderhandler.New(orderController, panelBroadcaster, productCache, appLogger)
+
+	// Liveness and readiness probes. Downstream services are only reported unless configured as required
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal(appLogger, "failed to get the database connection", err)
+	}
+	healthHandler := health.New(cfg.Health.CheckTimeout,
+		health.Check{Name: "database", Critical: true, Probe: sqlDB.PingContext},
+		health.Check{Name: "product_service", Critical: cfg.Health.RequireDownstream, Probe: productService.Available},
+		health.Check{Name: "payment_service", Critical: cfg.Health.RequireDownstream, Probe: paymentService.Available},
+	)
 
 	// Default Routes
 	r.GET("/ping", ping)
+	r.GET("/health/live", healthHandler.Live)
+	r.GET("/health/ready", healthHandler.Ready)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	r.GET("/swagger/*any", ginswagger.WrapHandler(swaggerfiles.Handler))
 
 	// Auth Service endpoints documentation
-	r.GET("/auth-info", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message":          "Admin authentication is now centralized",
-			"auth_service_url": "http://localhost:8081",
-			"admin_register":   "POST http://localhost:8081/admin/register",
-			"admin_login":      "POST http://localhost:8081/admin/login",
-			"admin_validate":   "POST http://localhost:8081/admin/validate",
-		})
-	})
+	r.GET("/auth-info", authInfo(cfg.Auth))
+	r.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Webhooks called by other services
+	webhookRoutes := r.Group("/webhooks", middleware.Deadline(cfg.Server.WebhookTimeout))
+	webhookRoutes.POST("/payment", middleware.PaymentWebhookAuth(cfg.Webhooks.PaymentSecret), orderHandler.PaymentWebhook)
+
+	// Local admin accounts, for stores running without the central auth service. Their tokens stop
+	// being accepted once the account is disabled, the central service owns that check otherwise.
+	var adminController *admincontroller.Controller
+	var adminAccounts middleware.AdminAccounts
+	if cfg.Auth.Mode == config.AuthModeLocal {
+		adminController = admincontroller.Build(admindatasource.New(db), admingateway.NewAuthGateway(authController))
+		adminAccounts = adminController
+	}
 
 	// Authenticated Group
 	authenticated := r.Group("/")
-	authenticated.Use(middleware.AuthMiddleware(authController))
+	authenticated.Use(middleware.AuthMiddleware(authController, adminAccounts, authentity.Role(cfg.Auth.DefaultAdminRole)))
 
-	// Admin Routes
+	// Admin Routes, for staff users. Each route requires the permissions of the roles allowed to use it.
 	adminRoutes := authenticated.Group("/admin")
 	adminRoutes.Use(middleware.AdminOnly())
 
+	readOrders := middleware.RequirePermission(authentity.PermissionOrdersRead)
+	readReports := middleware.RequirePermission(authentity.PermissionReportsRead)
+	manageOperations := middleware.RequirePermission(authentity.PermissionOperationsManage)
+	manageAccounts := middleware.RequirePermission(authentity.PermissionAccountsManage)
+
+	// The panel stream lasts as long as the panel is open, every other admin route has a deadline
+	adminRoutes.GET("/orders/panel/stream", readOrders, orderHandler.StreamPanel)
+	adminAPI := adminRoutes.Group("", middleware.Deadline(cfg.Server.RequestTimeout))
+
 	// Order Management Routes
-	adminRoutes.GET("/orders", orderHandler.GetAll)
-	adminRoutes.PUT("/orders/:id", orderHandler.Update)
-	adminRoutes.GET("/orders/panel", orderHandler.GetPanel)
+	adminAPI.GET("/orders", readOrders, orderHandler.GetAll)
+	adminAPI.PUT("/orders/:id", middleware.RequirePermission(authentity.PermissionOrdersAdvance), orderHandler.Update)
+	adminAPI.POST("/orders/:id/cancel", middleware.RequirePermission(authentity.PermissionOrdersCancel), orderHandler.Cancel)
+	adminAPI.GET("/orders/:id/history", readOrders, orderHandler.GetHistory)
+	adminAPI.GET("/orders/:id/payment", readOrders, orderHandler.GetPayment)
+	adminAPI.GET("/orders/panel", readOrders, orderHandler.GetPanel)
+
+	// Outbox Routes
+	adminAPI.GET("/outbox", readReports, orderHandler.GetStuckOutbox)
+	adminAPI.POST("/outbox/:id/retry", manageOperations, orderHandler.RetryOutbox)
+
+	// Product Cache Routes
+	adminAPI.GET("/products/cache", readReports, orderHandler.GetProductCacheStats)
+	adminAPI.DELETE("/products/cache/:id", manageOperations, orderHandler.InvalidateProductCache)
+
+	if cfg.Auth.Mode == config.AuthModeLocal {
+		adminHandler := adminhandler.New(adminController)
+
+		if err := bootstrapAdmin(adminController, cfg.Auth.BootstrapAdmin, cfg.Server.RequestTimeout); err != nil {
+			fatal(appLogger, "failed to create the bootstrap admin", err)
+		}
+
+		// Login, refresh and validate are public, new admins are registered by an admin
+		accountRoutes := r.Group("/admin", middleware.Deadline(cfg.Server.RequestTimeout))
+		accountRoutes.POST("/login", adminHandler.Login)
+		accountRoutes.POST("/token/refresh", adminHandler.Refresh)
+		accountRoutes.GET("/validate", adminHandler.ValidateToken)
+		adminAPI.POST("/logout", adminHandler.Logout)
+		adminAPI.POST("/register", manageAccounts, adminHandler.Register)
+
+		// Admin Account Routes. Every staff user can change their own password.
+		adminAPI.GET("/accounts", manageAccounts, adminHandler.List)
+		adminAPI.GET("/accounts/:id", manageAccounts, adminHandler.FindByID)
+		adminAPI.PUT("/accounts/:id/role", manageAccounts, adminHandler.SetRole)
+		adminAPI.POST("/accounts/:id/disable", manageAccounts, adminHandler.Disable)
+		adminAPI.POST("/accounts/:id/enable", manageAccounts, adminHandler.Enable)
+		adminAPI.POST("/accounts/:id/password/reset", manageAccounts, adminHandler.ResetPassword)
+		adminAPI.DELETE("/accounts/:id", manageAccounts, adminHandler.Delete)
+		adminAPI.PUT("/me/password", adminHandler.ChangePassword)
+	}
 
-	r.Run(":8083")
+	server := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// Panel streams never end on their own, closing the broadcaster ends them so the drain can finish
+	server.RegisterOnShutdown(panelBroadcaster.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal(appLogger, "failed to start the server", err)
+		}
+	}()
+
+	appLogger.Info("server started", slog.String("port", cfg.Server.Port))
+
+	<-ctx.Done()
+	shutdown(appLogger, server, healthHandler, cfg.Server)
+
+	outboxDispatcher.Stop()
+	if err := sqlDB.Close(); err != nil {
+		appLogger.Error("failed to close the database", slog.String("error", err.Error()))
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		appLogger.Error("failed to flush the pending spans", slog.String("error", err.Error()))
+	}
+	appLogger.Info("server stopped")
 }
 
-func loadYAML() {
-	viper.SetConfigName("default")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./conf/environment")
+// shutdown reports the service unready, keeps serving for the drain delay and then stops accepting
+// connections, waiting for in-flight requests up to the shutdown timeout
+func shutdown(logger *slog.Logger, server *http.Server, healthHandler *health.Handler, cfg config.ServerConfig) {
+	logger.Info("shutting down, draining in-flight requests", slog.Duration("drain_delay", cfg.DrainDelay))
+	healthHandler.SetShuttingDown()
+	time.Sleep(cfg.DrainDelay)
 
-	err := viper.ReadInConfig()
-	if err != nil {
-		log.Fatalf("error reading yaml config: %v", err)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("failed to drain in-flight requests", slog.String("error", err.Error()))
+	}
+}
+
+// authInfo tells clients where admins register and log in
+func authInfo(cfg config.AuthConfig) gin.HandlerFunc {
+	if cfg.Mode == config.AuthModeLocal {
+		return func(c *gin.Context) {
+			c.JSON(200, gin.H{
+				"message":        "Admin authentication is served by this service",
+				"mode":           cfg.Mode,
+				"admin_register": "POST /admin/register",
+				"admin_login":    "POST /admin/login",
+				"admin_refresh":  "POST /admin/token/refresh",
+				"admin_logout":   "POST /admin/logout",
+				"jwks":           "GET /.well-known/jwks.json",
+				"admin_validate": "GET /admin/validate",
+			})
+		}
+	}
+
+	return func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message":          "Admin authentication is now centralized",
+			"mode":             cfg.Mode,
+			"auth_service_url": cfg.ServiceURL,
+			"admin_register":   "POST " + cfg.ServiceURL + "/admin/register",
+			"admin_login":      "POST " + cfg.ServiceURL + "/admin/login",
+			"admin_validate":   "POST " + cfg.ServiceURL + "/admin/validate",
+		})
+	}
+}
+
+// tokenKeys returns the shared secret for HS256, or the key ring of the asymmetric signing methods. Keys
+// keep verifying for an access token lifetime after the next key activates.
+func tokenKeys(cfg config.AuthConfig) (external.KeySource, error) {
+	if cfg.SigningMethod == config.SigningMethodHS256 {
+		return external.NewHMACKey(cfg.SecretKey), nil
+	}
+	return external.LoadKeyRing(cfg.SigningMethod, cfg.KeysDir, cfg.TokenExpiry)
+}
+
+// bootstrapAdmin creates the configured admin, with the admin role, unless it already exists
+func bootstrapAdmin(controller *admincontroller.Controller, admin config.BootstrapAdminConfig, timeout time.Duration) error {
+	if admin.Email == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := controller.Register(ctx, admindto.RegisterRequestDTO{
+		Email:    admin.Email,
+		Password: admin.Password,
+		Role:     string(authentity.RoleAdmin),
+	})
+	var conflictErr *apperror.ConflictError
+	if errors.As(err, &conflictErr) {
+		return nil
+	}
+	return err
+}
+
+// fatal logs the error and exits, for failures the service cannot start without
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, slog.String("error", err.Error()))
+	os.Exit(1)
+}
+
+// clientConfig applies the configured settings of a downstream service over the client defaults
+func clientConfig(service config.ServiceConfig) httpclient.Config {
+	clientConfig := httpclient.DefaultConfig()
+	clientConfig.Timeout = service.Timeout
+	clientConfig.MaxRetries = service.MaxRetries
+	clientConfig.BreakerFailureThreshold = service.BreakerFailureThreshold
+	clientConfig.BreakerOpenTimeout = service.BreakerOpenTimeout
+	return clientConfig
+}
+
+func preparationEstimatorConfig(kitchen config.KitchenConfig) orderentity.PreparationEstimatorConfig {
+	capacity := make(map[orderenum.KitchenStation]uint, len(kitchen.StationCapacity))
+	for station, items := range kitchen.StationCapacity {
+		capacity[orderenum.KitchenStation(station)] = items
 	}
+	return orderentity.PreparationEstimatorConfig{StationCapacity: capacity}
 }
 
 // Ping godoc
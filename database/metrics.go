@@ -0,0 +1,71 @@
+package database
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+const queryStartKey = "metrics:query_start"
+
+var dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "db_query_duration_seconds",
+	Help:    "Duration of the database statements run through GORM, by operation and table.",
+	Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "table"})
+
+// queryMetrics is a GORM plugin timing every statement
+type queryMetrics struct{}
+
+func (queryMetrics) Name() string {
+	return "query_metrics"
+}
+
+func (queryMetrics) Initialize(db *gorm.DB) error {
+	type register func(name string, fn func(*gorm.DB)) error
+
+	callbacks := []struct {
+		operation string
+		before    register
+		after     register
+	}{
+		{"create", db.Callback().Create().Before("*").Register, db.Callback().Create().After("*").Register},
+		{"query", db.Callback().Query().Before("*").Register, db.Callback().Query().After("*").Register},
+		{"update", db.Callback().Update().Before("*").Register, db.Callback().Update().After("*").Register},
+		{"delete", db.Callback().Delete().Before("*").Register, db.Callback().Delete().After("*").Register},
+		{"row", db.Callback().Row().Before("*").Register, db.Callback().Row().After("*").Register},
+		{"raw", db.Callback().Raw().Before("*").Register, db.Callback().Raw().After("*").Register},
+	}
+
+	for _, callback := range callbacks {
+		if err := callback.before("metrics:before_"+callback.operation, startQuery); err != nil {
+			return err
+		}
+		if err := callback.after("metrics:after_"+callback.operation, observeQuery(callback.operation)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func observeQuery(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		dbQueryDuration.WithLabelValues(operation, db.Statement.Table).Observe(time.Since(start).Seconds())
+	}
+}
@@ -1,12 +1,13 @@
 package database
 
 import (
-	"log"
-	"os"
+	"fmt"
 	"sync"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/config"
 )
 
 type postgresDatabase struct {
@@ -16,24 +17,46 @@ type postgresDatabase struct {
 var (
 	once       sync.Once
 	dbInstance *postgresDatabase
+	dbErr      error
 )
 
-func NewPostgresDatabase() Database {
+func NewPostgresDatabase(cfg config.DatabaseConfig) (Database, error) {
 	once.Do(func() {
-		dsn := os.Getenv("DATABASE_URL")
-		if dsn == "" {
-			log.Fatal("DATABASE_URL não encontrada no ambiente")
+		db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+			// Driver errors such as unique violations become gorm errors the gateways can translate
+			TranslateError: true,
+		})
+		if err != nil {
+			dbErr = fmt.Errorf("error connecting to the database: %w", err)
+			return
+		}
+
+		if err := db.Use(queryMetrics{}); err != nil {
+			dbErr = fmt.Errorf("error registering database metrics: %w", err)
+			return
+		}
+		if err := db.Use(queryTracing{}); err != nil {
+			dbErr = fmt.Errorf("error registering database tracing: %w", err)
+			return
 		}
 
-		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		sqlDB, err := db.DB()
 		if err != nil {
-			log.Fatalf("Erro ao conectar no banco: %v", err)
+			dbErr = fmt.Errorf("error configuring the connection pool: %w", err)
+			return
 		}
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 		dbInstance = &postgresDatabase{Db: db}
 	})
 
-	return dbInstance
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	return dbInstance, nil
 }
 
 func (p *postgresDatabase) GetDb() *gorm.DB {
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/tracing"
+)
+
+const querySpanKey = "tracing:query_span"
+
+// queryTracing is a GORM plugin tracing every statement as a child of the span in the statement
+// context, so queries run with WithContext(ctx) show up under the request that ran them
+type queryTracing struct{}
+
+func (queryTracing) Name() string {
+	return "query_tracing"
+}
+
+func (queryTracing) Initialize(db *gorm.DB) error {
+	type register func(name string, fn func(*gorm.DB)) error
+
+	callbacks := []struct {
+		operation string
+		before    register
+		after     register
+	}{
+		{"create", db.Callback().Create().Before("*").Register, db.Callback().Create().After("*").Register},
+		{"query", db.Callback().Query().Before("*").Register, db.Callback().Query().After("*").Register},
+		{"update", db.Callback().Update().Before("*").Register, db.Callback().Update().After("*").Register},
+		{"delete", db.Callback().Delete().Before("*").Register, db.Callback().Delete().After("*").Register},
+		{"row", db.Callback().Row().Before("*").Register, db.Callback().Row().After("*").Register},
+		{"raw", db.Callback().Raw().Before("*").Register, db.Callback().Raw().After("*").Register},
+	}
+
+	tracer := otel.Tracer(tracing.InstrumentationName)
+	for _, callback := range callbacks {
+		if err := callback.before("tracing:before_"+callback.operation, startSpan(tracer, callback.operation)); err != nil {
+			return err
+		}
+		if err := callback.after("tracing:after_"+callback.operation, endSpan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func startSpan(tracer trace.Tracer, operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		name := operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+
+		_, span := tracer.Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemPostgreSQL,
+				semconv.DBOperationName(operation),
+				semconv.DBCollectionName(db.Statement.Table),
+			),
+		)
+		db.InstanceSet(querySpanKey, span)
+	}
+}
+
+func endSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(querySpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	// The statement keeps its placeholders, the bound values are never recorded
+	span.SetAttributes(semconv.DBQueryText(db.Statement.SQL.String()))
+
+	// A missing row is an expected answer, not a failed query
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
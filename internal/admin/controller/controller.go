@@ -2,11 +2,14 @@ package controller
 
 import (
 	"context"
+	"errors"
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/dto"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/external/datasource"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/gateway"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/usecases"
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
 )
 
 type Controller struct {
@@ -21,10 +24,10 @@ func Build(productDataSource datasource.DataSource, authGateway gateway.AuthGate
 	}
 }
 
-func (c *Controller) Register(ctx context.Context, adminRequest dto.AdminRequestDTO) error {
+func (c *Controller) Register(ctx context.Context, adminRequest dto.RegisterRequestDTO) error {
 	adminGateway := gateway.Build(c.AdminDatasource)
 	useCase := usecases.Build(*adminGateway)
-	admin := dto.FromAdminRequestDTO(adminRequest)
+	admin := dto.FromRegisterRequestDTO(adminRequest)
 	err := useCase.Create(ctx, admin)
 
 	if err != nil {
@@ -35,44 +38,184 @@ func (c *Controller) Register(ctx context.Context, adminRequest dto.AdminRequest
 
 }
 
-func (c *Controller) Login(ctx context.Context, adminRequest dto.AdminRequestDTO) (string, error) {
+// Login starts a session for the admin, with a short lived access token and a refresh token
+func (c *Controller) Login(ctx context.Context, adminRequest dto.AdminRequestDTO) (authentity.TokenPair, error) {
 	adminGateway := gateway.Build(c.AdminDatasource)
 	useCase := usecases.Build(*adminGateway)
 	admin := dto.FromAdminRequestDTO(adminRequest)
-	adminId, _, err := useCase.Login(ctx, admin)
+	saved, err := useCase.Login(ctx, admin)
 
 	if err != nil {
-		return "", err
+		return authentity.TokenPair{}, err
 	}
 
-	token, err2 := c.AuthGateway.GenerateToken(adminId, "admin", nil)
+	tokens, err2 := c.AuthGateway.IssueTokens(ctx, saved.Id, "admin", saved.Role, "")
 
 	if err2 != nil {
-		return "", err
+		return authentity.TokenPair{}, err2
 	}
 
-	return token, nil
+	return tokens, nil
+}
+
+// Refresh rotates the refresh token and issues new tokens with the current role of the admin. The session
+// of an admin disabled or deleted since the login is revoked instead.
+func (c *Controller) Refresh(ctx context.Context, request dto.RefreshTokenRequestDTO) (authentity.TokenPair, error) {
+	session, err := c.AuthGateway.CheckRefreshToken(ctx, request.RefreshToken)
+	if err != nil {
+		return authentity.TokenPair{}, err
+	}
+
+	admin, err := c.useCase().FindByID(ctx, session.UserID)
+	var notFoundErr *apperror.NotFoundError
+	if errors.As(err, &notFoundErr) || (err == nil && admin.Disabled) {
+		if err := c.AuthGateway.RevokeSession(ctx, session.FamilyID); err != nil {
+			return authentity.TokenPair{}, err
+		}
+		return authentity.TokenPair{}, &apperror.UnauthorizedError{Msg: "Admin account is disabled"}
+	}
+	if err != nil {
+		return authentity.TokenPair{}, err
+	}
+
+	return c.AuthGateway.RotateRefreshToken(ctx, session, admin.Role)
+}
+
+// Logout revokes the session the token belongs to
+func (c *Controller) Logout(ctx context.Context, claims *authentity.CustomClaims) error {
+	return c.AuthGateway.Logout(ctx, claims)
 }
 
 // ValidateToken validates a JWT token and returns admin information
 func (c *Controller) ValidateToken(ctx context.Context, token string) (bool, map[string]interface{}) {
 	// Use AuthGateway to validate token (which is the auth controller)
-	claims, err := c.AuthGateway.ValidateToken(token)
+	claims, err := c.AuthGateway.ValidateToken(ctx, token)
 	if err != nil {
 		return false, nil
 	}
 
-	// Check if the token is for admin role
-	role, ok := claims["role"].(string)
-	if !ok || role != "admin" {
+	// Check if the token is for an admin user
+	userType, ok := claims["user_type"].(string)
+	if !ok || userType != "admin" {
+		return false, nil
+	}
+
+	// Tokens of admins disabled or deleted since they logged in are no longer valid
+	adminID, _ := claims["user_id"].(string)
+	admin, err := c.useCase().FindByID(ctx, adminID)
+	if err != nil || admin.Disabled {
 		return false, nil
 	}
 
-	// Extract admin info from claims
+	// The role is the current one, tokens keep the role of their login until they expire
 	adminData := map[string]interface{}{
-		"id":   claims["user_id"],
-		"role": claims["role"],
+		"id":          claims["user_id"],
+		"role":        admin.Role,
+		"permissions": admin.Role.Permissions(),
 	}
 
 	return true, adminData
 }
+
+// AccountActive tells whether the admin still exists and is enabled, so the tokens issued before it was
+// disabled or deleted stop being accepted
+func (c *Controller) AccountActive(ctx context.Context, id string) (bool, error) {
+	admin, err := c.useCase().FindByID(ctx, id)
+	var notFoundErr *apperror.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return !admin.Disabled, nil
+}
+
+func (c *Controller) List(ctx context.Context) ([]dto.AdminResponseDTO, error) {
+	admins, err := c.useCase().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.ToAdminResponseDTOList(admins), nil
+}
+
+func (c *Controller) FindByID(ctx context.Context, id string) (dto.AdminResponseDTO, error) {
+	admin, err := c.useCase().FindByID(ctx, id)
+	if err != nil {
+		return dto.AdminResponseDTO{}, err
+	}
+
+	return dto.ToAdminResponseDTO(admin), nil
+}
+
+// SetDisabled disables or enables the admin on behalf of actorID. Disabling revokes the sessions of the
+// admin, so a lost device is locked out at once.
+func (c *Controller) SetDisabled(ctx context.Context, id string, disabled bool, actorID string) (dto.AdminResponseDTO, error) {
+	admin, err := c.useCase().SetDisabled(ctx, id, disabled, actorID)
+	if err != nil {
+		return dto.AdminResponseDTO{}, err
+	}
+
+	if disabled {
+		if err := c.AuthGateway.RevokeUserSessions(ctx, id); err != nil {
+			return dto.AdminResponseDTO{}, err
+		}
+	}
+
+	return dto.ToAdminResponseDTO(admin), nil
+}
+
+// SetRole changes the role of the admin on behalf of actorID and revokes their sessions, so no token
+// keeps the permissions of the old role
+func (c *Controller) SetRole(ctx context.Context, id string, request dto.RoleRequestDTO, actorID string) (dto.AdminResponseDTO, error) {
+	admin, err := c.useCase().SetRole(ctx, id, authentity.Role(request.Role), actorID)
+	if err != nil {
+		return dto.AdminResponseDTO{}, err
+	}
+
+	if err := c.AuthGateway.RevokeUserSessions(ctx, id); err != nil {
+		return dto.AdminResponseDTO{}, err
+	}
+
+	return dto.ToAdminResponseDTO(admin), nil
+}
+
+// ChangePassword also revokes the sessions of the admin, including the current one, so a session
+// opened with the old password does not outlive it
+func (c *Controller) ChangePassword(ctx context.Context, id string, request dto.ChangePasswordRequestDTO) error {
+	if err := c.useCase().ChangePassword(ctx, id, request.CurrentPassword, request.NewPassword); err != nil {
+		return err
+	}
+
+	return c.AuthGateway.RevokeUserSessions(ctx, id)
+}
+
+// ResetPassword also revokes the sessions of the admin, whose password may have leaked
+func (c *Controller) ResetPassword(ctx context.Context, id string) (dto.PasswordResetResponseDTO, error) {
+	password, err := c.useCase().ResetPassword(ctx, id)
+	if err != nil {
+		return dto.PasswordResetResponseDTO{}, err
+	}
+
+	if err := c.AuthGateway.RevokeUserSessions(ctx, id); err != nil {
+		return dto.PasswordResetResponseDTO{}, err
+	}
+
+	return dto.PasswordResetResponseDTO{TemporaryPassword: password}, nil
+}
+
+// Delete removes the admin on behalf of actorID and revokes their sessions
+func (c *Controller) Delete(ctx context.Context, id string, actorID string) error {
+	if err := c.useCase().Delete(ctx, id, actorID); err != nil {
+		return err
+	}
+
+	return c.AuthGateway.RevokeUserSessions(ctx, id)
+}
+
+func (c *Controller) useCase() *usecases.UseCases {
+	adminGateway := gateway.Build(c.AdminDatasource)
+	return usecases.Build(*adminGateway)
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/dto"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/gateway"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/utils"
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+)
+
+// fakeDataSource keeps the admins in memory, by id
+type fakeDataSource map[string]dto.AdminDAO
+
+func (f fakeDataSource) Create(_ context.Context, admin dto.AdminDAO) error {
+	f[admin.ID] = admin
+	return nil
+}
+
+func (f fakeDataSource) FindByEmail(_ context.Context, email string) (dto.AdminDAO, error) {
+	for _, admin := range f {
+		if admin.Email == email {
+			return admin, nil
+		}
+	}
+	return dto.AdminDAO{}, gorm.ErrRecordNotFound
+}
+
+func (f fakeDataSource) FindByID(_ context.Context, id string) (dto.AdminDAO, error) {
+	admin, ok := f[id]
+	if !ok {
+		return dto.AdminDAO{}, gorm.ErrRecordNotFound
+	}
+	return admin, nil
+}
+
+func (f fakeDataSource) List(context.Context) ([]dto.AdminDAO, error) {
+	admins := make([]dto.AdminDAO, 0, len(f))
+	for _, admin := range f {
+		admins = append(admins, admin)
+	}
+	return admins, nil
+}
+
+func (f fakeDataSource) Update(_ context.Context, admin dto.AdminDAO) error {
+	if _, ok := f[admin.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	f[admin.ID] = admin
+	return nil
+}
+
+func (f fakeDataSource) Delete(_ context.Context, id string) error {
+	if _, ok := f[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(f, id)
+	return nil
+}
+
+// fakeAuthGateway records the users whose sessions were revoked, the other calls are not expected
+type fakeAuthGateway struct {
+	gateway.AuthGateway
+	revoked []string
+}
+
+func (f *fakeAuthGateway) RevokeUserSessions(_ context.Context, userID string) error {
+	f.revoked = append(f.revoked, userID)
+	return nil
+}
+
+func TestController_RevokesSessions(t *testing.T) {
+	hash, err := utils.HashPassword("kitchen-secret")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		action      func(c *Controller) error
+		wantErr     bool
+		wantRevoked []string
+	}{
+		{
+			name: "role change",
+			action: func(c *Controller) error {
+				_, err := c.SetRole(context.Background(), "2", dto.RoleRequestDTO{Role: string(authentity.RoleCashier)}, "1")
+				return err
+			},
+			wantRevoked: []string{"2"},
+		},
+		{
+			name: "password change",
+			action: func(c *Controller) error {
+				return c.ChangePassword(context.Background(), "2", dto.ChangePasswordRequestDTO{CurrentPassword: "kitchen-secret", NewPassword: "new-kitchen-secret"})
+			},
+			wantRevoked: []string{"2"},
+		},
+		{
+			name: "rejected password change",
+			action: func(c *Controller) error {
+				return c.ChangePassword(context.Background(), "2", dto.ChangePasswordRequestDTO{CurrentPassword: "guess", NewPassword: "new-kitchen-secret"})
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			datasource := fakeDataSource{}
+			for _, admin := range []entity.Admin{
+				{Id: "1", Email: "manager@golunch.com", Password: hash, Role: authentity.RoleManager},
+				{Id: "2", Email: "chef@golunch.com", Password: hash, Role: authentity.RoleKitchenStaff},
+			} {
+				datasource[admin.Id] = dto.ToAdminDAO(admin)
+			}
+			auth := &fakeAuthGateway{}
+
+			err := tt.action(Build(datasource, auth))
+
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantRevoked, auth.revoked)
+		})
+	}
+}
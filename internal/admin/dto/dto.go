@@ -4,8 +4,10 @@ import (
 	"time"
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/entity"
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 	gormEntity "github.com/fiap-161/tc-golunch-operation-service/internal/shared/entity"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type AdminRequestDTO struct {
@@ -13,30 +15,97 @@ type AdminRequestDTO struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RegisterRequestDTO registers a staff account with one of the roles kitchen_staff, cashier, manager or admin
+type RegisterRequestDTO struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role" binding:"required" example:"kitchen_staff"`
+}
+
+// RoleRequestDTO changes the role of an admin account
+type RoleRequestDTO struct {
+	Role string `json:"role" binding:"required" example:"cashier"`
+}
+
+// RefreshTokenRequestDTO exchanges a refresh token for new tokens. The refresh token can only be used once.
+type RefreshTokenRequestDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// ChangePasswordRequestDTO changes the password of the logged in admin, who must know the current one
+type ChangePasswordRequestDTO struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// AdminResponseDTO is an admin account as listed to other admins, without its password
+type AdminResponseDTO struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PasswordResetResponseDTO carries the temporary password of a reset account. It is shown only once.
+type PasswordResetResponseDTO struct {
+	TemporaryPassword string `json:"temporary_password"`
+}
+
+// AdminDAO is soft deleted, so the email of a deleted admin can be registered again
 type AdminDAO struct {
 	gormEntity.Entity
-	Email    string `json:"email" gorm:"unique"`
-	Password string `json:"password"`
+	Email     string         `json:"email" gorm:"uniqueIndex:idx_admin_daos_email,where:deleted_at IS NULL"`
+	Password  string         `json:"password"`
+	Role      string         `json:"role" gorm:"not null;default:admin"`
+	Disabled  bool           `json:"disabled" gorm:"not null;default:false"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 func ToAdminDAO(admin entity.Admin) AdminDAO {
-	return AdminDAO{
+	now := time.Now()
+	dao := AdminDAO{
 		Entity: gormEntity.Entity{
-			ID:        uuid.NewString(),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			ID:        admin.Id,
+			CreatedAt: admin.CreatedAt,
+			UpdatedAt: admin.UpdatedAt,
 		},
 		Email:    admin.Email,
 		Password: admin.Password,
+		Role:     string(admin.Role),
+		Disabled: admin.Disabled,
+	}
+	if dao.ID == "" {
+		dao.ID = uuid.NewString()
+	}
+	if dao.CreatedAt.IsZero() {
+		dao.CreatedAt = now
 	}
+	if dao.UpdatedAt.IsZero() {
+		dao.UpdatedAt = now
+	}
+	return dao
 }
 
 func FromAdminDAO(dao AdminDAO) entity.Admin {
 	return entity.Admin{
-		Id:       dao.ID,
-		Email:    dao.Email,
-		Password: dao.Password,
+		Id:        dao.ID,
+		Email:     dao.Email,
+		Password:  dao.Password,
+		Role:      authentity.Role(dao.Role),
+		Disabled:  dao.Disabled,
+		CreatedAt: dao.CreatedAt,
+		UpdatedAt: dao.UpdatedAt,
+	}
+}
+
+func FromAdminDAOList(daos []AdminDAO) []entity.Admin {
+	admins := make([]entity.Admin, len(daos))
+	for i, dao := range daos {
+		admins[i] = FromAdminDAO(dao)
 	}
+	return admins
 }
 
 func FromAdminRequestDTO(dto AdminRequestDTO) entity.Admin {
@@ -45,3 +114,30 @@ func FromAdminRequestDTO(dto AdminRequestDTO) entity.Admin {
 		Password: dto.Password,
 	}
 }
+
+func FromRegisterRequestDTO(dto RegisterRequestDTO) entity.Admin {
+	return entity.Admin{
+		Email:    dto.Email,
+		Password: dto.Password,
+		Role:     authentity.Role(dto.Role),
+	}
+}
+
+func ToAdminResponseDTO(admin entity.Admin) AdminResponseDTO {
+	return AdminResponseDTO{
+		ID:        admin.Id,
+		Email:     admin.Email,
+		Role:      string(admin.Role),
+		Disabled:  admin.Disabled,
+		CreatedAt: admin.CreatedAt,
+		UpdatedAt: admin.UpdatedAt,
+	}
+}
+
+func ToAdminResponseDTOList(admins []entity.Admin) []AdminResponseDTO {
+	response := make([]AdminResponseDTO, len(admins))
+	for i, admin := range admins {
+		response[i] = ToAdminResponseDTO(admin)
+	}
+	return response
+}
@@ -1,9 +1,19 @@
 package entity
 
+import (
+	"time"
+
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+)
+
 type Admin struct {
-	Id       string
-	Email    string
-	Password string
+	Id        string
+	Email     string
+	Password  string
+	Role      authentity.Role
+	Disabled  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 func (a Admin) Build(password string) Admin {
@@ -11,5 +21,28 @@ func (a Admin) Build(password string) Admin {
 		Id:       a.Id,
 		Email:    a.Email,
 		Password: password,
+		Role:     a.Role,
 	}
 }
+
+// WithRole returns the admin with a new role, granted on the next login
+func (a Admin) WithRole(role authentity.Role) Admin {
+	a.Role = role
+	a.UpdatedAt = time.Now()
+	return a
+}
+
+// WithPassword returns the admin with a new password hash
+func (a Admin) WithPassword(hash string) Admin {
+	a.Password = hash
+	a.UpdatedAt = time.Now()
+	return a
+}
+
+// WithDisabled returns the admin disabled or enabled again. Disabled admins cannot log in and their
+// tokens are rejected by AuthMiddleware.
+func (a Admin) WithDisabled(disabled bool) Admin {
+	a.Disabled = disabled
+	a.UpdatedAt = time.Now()
+	return a
+}
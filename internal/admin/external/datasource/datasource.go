@@ -9,4 +9,8 @@ import (
 type DataSource interface {
 	Create(ctx context.Context, admin dto.AdminDAO) error
 	FindByEmail(ctx context.Context, email string) (dto.AdminDAO, error)
+	FindByID(ctx context.Context, id string) (dto.AdminDAO, error)
+	List(ctx context.Context) ([]dto.AdminDAO, error)
+	Update(ctx context.Context, admin dto.AdminDAO) error
+	Delete(ctx context.Context, id string) error
 }
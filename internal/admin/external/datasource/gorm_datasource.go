@@ -11,6 +11,7 @@ type DB interface {
 	Create(value any) *gorm.DB
 	Where(query any, args ...any) *gorm.DB
 	First(dest any, conds ...any) *gorm.DB
+	WithContext(ctx context.Context) *gorm.DB
 }
 
 type GormDataSource struct {
@@ -23,8 +24,8 @@ func New(db DB) *GormDataSource {
 	}
 }
 
-func (r *GormDataSource) Create(_ context.Context, admin dto.AdminDAO) error {
-	tx := r.db.Create(&admin)
+func (r *GormDataSource) Create(ctx context.Context, admin dto.AdminDAO) error {
+	tx := r.db.WithContext(ctx).Create(&admin)
 	if tx.Error != nil {
 		return tx.Error
 	}
@@ -32,10 +33,10 @@ func (r *GormDataSource) Create(_ context.Context, admin dto.AdminDAO) error {
 	return nil
 }
 
-func (r *GormDataSource) FindByEmail(_ context.Context, email string) (dto.AdminDAO, error) {
+func (r *GormDataSource) FindByEmail(ctx context.Context, email string) (dto.AdminDAO, error) {
 	var admin dto.AdminDAO
 
-	tx := r.db.Where("email = ?", email).First(&admin)
+	tx := r.db.WithContext(ctx).Where("email = ?", email).First(&admin)
 
 	if tx.Error != nil {
 		return dto.AdminDAO{}, tx.Error
@@ -43,3 +44,54 @@ func (r *GormDataSource) FindByEmail(_ context.Context, email string) (dto.Admin
 
 	return admin, nil
 }
+
+func (r *GormDataSource) FindByID(ctx context.Context, id string) (dto.AdminDAO, error) {
+	var admin dto.AdminDAO
+
+	tx := r.db.WithContext(ctx).First(&admin, "id = ?", id)
+	if tx.Error != nil {
+		return dto.AdminDAO{}, tx.Error
+	}
+
+	return admin, nil
+}
+
+func (r *GormDataSource) List(ctx context.Context) ([]dto.AdminDAO, error) {
+	var admins []dto.AdminDAO
+
+	if err := r.db.WithContext(ctx).Order("email").Find(&admins).Error; err != nil {
+		return nil, err
+	}
+
+	return admins, nil
+}
+
+// Update saves the email, password, role and disabled flag of the admin
+func (r *GormDataSource) Update(ctx context.Context, admin dto.AdminDAO) error {
+	tx := r.db.WithContext(ctx).
+		Model(&dto.AdminDAO{}).
+		Where("id = ?", admin.ID).
+		Select("email", "password", "role", "disabled", "updated_at").
+		Updates(&admin)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete soft deletes the admin, keeping the row for auditing
+func (r *GormDataSource) Delete(ctx context.Context, id string) error {
+	tx := r.db.WithContext(ctx).Delete(&dto.AdminDAO{}, "id = ?", id)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -1,6 +1,17 @@
 package gateway
 
+import (
+	"context"
+
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+)
+
 type AuthGateway interface {
-	GenerateToken(userID string, userType string, additionalClaims map[string]any) (string, error)
-	ValidateToken(token string) (map[string]interface{}, error)
+	IssueTokens(ctx context.Context, userID string, userType string, role authentity.Role, familyID string) (authentity.TokenPair, error)
+	CheckRefreshToken(ctx context.Context, refreshToken string) (authentity.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, used authentity.RefreshToken, role authentity.Role) (authentity.TokenPair, error)
+	Logout(ctx context.Context, claims *authentity.CustomClaims) error
+	RevokeSession(ctx context.Context, familyID string) error
+	RevokeUserSessions(ctx context.Context, userID string) error
+	ValidateToken(ctx context.Context, token string) (map[string]interface{}, error)
 }
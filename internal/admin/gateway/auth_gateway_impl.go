@@ -1,7 +1,10 @@
 package gateway
 
 import (
+	"context"
+
 	authcontroller "github.com/fiap-161/tc-golunch-operation-service/internal/auth/controller"
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 )
 
 // AuthGatewayImpl implementa AuthGateway usando o auth controller
@@ -15,12 +18,32 @@ func NewAuthGateway(authController *authcontroller.Controller) AuthGateway {
 	}
 }
 
-func (a *AuthGatewayImpl) GenerateToken(userID string, userType string, additionalClaims map[string]any) (string, error) {
-	return a.authController.GenerateToken(userID, userType, additionalClaims)
+func (a *AuthGatewayImpl) IssueTokens(ctx context.Context, userID string, userType string, role authentity.Role, familyID string) (authentity.TokenPair, error) {
+	return a.authController.IssueTokens(ctx, userID, userType, role, familyID)
+}
+
+func (a *AuthGatewayImpl) CheckRefreshToken(ctx context.Context, refreshToken string) (authentity.RefreshToken, error) {
+	return a.authController.CheckRefreshToken(ctx, refreshToken)
+}
+
+func (a *AuthGatewayImpl) RotateRefreshToken(ctx context.Context, used authentity.RefreshToken, role authentity.Role) (authentity.TokenPair, error) {
+	return a.authController.RotateRefreshToken(ctx, used, role)
+}
+
+func (a *AuthGatewayImpl) Logout(ctx context.Context, claims *authentity.CustomClaims) error {
+	return a.authController.Logout(ctx, claims)
+}
+
+func (a *AuthGatewayImpl) RevokeSession(ctx context.Context, familyID string) error {
+	return a.authController.RevokeSession(ctx, familyID)
+}
+
+func (a *AuthGatewayImpl) RevokeUserSessions(ctx context.Context, userID string) error {
+	return a.authController.RevokeUserSessions(ctx, userID)
 }
 
-func (a *AuthGatewayImpl) ValidateToken(token string) (map[string]interface{}, error) {
-	claims, err := a.authController.ValidateToken(token)
+func (a *AuthGatewayImpl) ValidateToken(ctx context.Context, token string) (map[string]interface{}, error) {
+	claims, err := a.authController.ValidateToken(ctx, token)
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +51,8 @@ func (a *AuthGatewayImpl) ValidateToken(token string) (map[string]interface{}, e
 	// Convert claims to map[string]interface{}
 	result := make(map[string]interface{})
 	result["user_id"] = claims.UserID
-	result["role"] = claims.UserType
+	result["user_type"] = claims.UserType
+	result["role"] = string(claims.Role)
 
 	return result, nil
 }
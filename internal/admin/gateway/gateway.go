@@ -9,6 +9,11 @@ import (
 	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
 )
 
+const (
+	adminNotFound   = "admin not found"
+	adminRegistered = "admin already registered"
+)
+
 type Gateway struct {
 	Datasource datasource.DataSource
 }
@@ -24,7 +29,7 @@ func (g *Gateway) Create(c context.Context, admin entity.Admin) error {
 	err := g.Datasource.Create(c, adminDAO)
 
 	if err != nil {
-		return &apperror.InternalError{Msg: err.Error()}
+		return apperror.FromStorage(err, adminNotFound, adminRegistered)
 	}
 
 	return nil
@@ -34,10 +39,44 @@ func (g *Gateway) FindByEmail(c context.Context, email string) (entity.Admin, er
 	adminDAO, err := g.Datasource.FindByEmail(c, email)
 
 	if err != nil {
-		return entity.Admin{}, &apperror.InternalError{Msg: err.Error()}
+		return entity.Admin{}, apperror.FromStorage(err, adminNotFound, adminRegistered)
 	}
 
 	admin := dto.FromAdminDAO(adminDAO)
 
 	return admin, nil
 }
+
+func (g *Gateway) FindByID(c context.Context, id string) (entity.Admin, error) {
+	adminDAO, err := g.Datasource.FindByID(c, id)
+	if err != nil {
+		return entity.Admin{}, apperror.FromStorage(err, adminNotFound, adminRegistered)
+	}
+
+	return dto.FromAdminDAO(adminDAO), nil
+}
+
+func (g *Gateway) List(c context.Context) ([]entity.Admin, error) {
+	adminsDAO, err := g.Datasource.List(c)
+	if err != nil {
+		return nil, apperror.FromStorage(err, adminNotFound, adminRegistered)
+	}
+
+	return dto.FromAdminDAOList(adminsDAO), nil
+}
+
+func (g *Gateway) Update(c context.Context, admin entity.Admin) error {
+	if err := g.Datasource.Update(c, dto.ToAdminDAO(admin)); err != nil {
+		return apperror.FromStorage(err, adminNotFound, adminRegistered)
+	}
+
+	return nil
+}
+
+func (g *Gateway) Delete(c context.Context, id string) error {
+	if err := g.Datasource.Delete(c, id); err != nil {
+		return apperror.FromStorage(err, adminNotFound, adminRegistered)
+	}
+
+	return nil
+}
@@ -1,11 +1,12 @@
 package handler
 
 import (
-	"context"
 	"net/http"
+	"time"
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/controller"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/dto"
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/helper"
 	"github.com/gin-gonic/gin"
@@ -23,21 +24,25 @@ func New(adminController *controller.Controller) *Handler {
 
 // Register godoc
 // @Summary      Register Admin
-// @Description  Register a new admin user
+// @Description  Register a new staff account with a role. Only available when the admins are stored locally
 // @Tags         Admin
+// @Security     BearerAuth
 // @Accept       json
 // @Produce      json
-// @Param        request  body      dto.AdminRequestDTO  true  "Admin registration details"
+// @Param        request  body      dto.RegisterRequestDTO  true  "Admin registration details"
 // @Success      201      {object}  map[string]string     "Success message"
 // @Failure      400      {object}  errors.ErrorDTO
+// @Failure      401      {object}  errors.ErrorDTO
+// @Failure      409      {object}  errors.ErrorDTO
 // @Failure      500      {object}  errors.ErrorDTO
 // @Router       /admin/register [post]
 func (h *Handler) Register(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
-	var adminRequest dto.AdminRequestDTO
+	var adminRequest dto.RegisterRequestDTO
 	if err := c.ShouldBindJSON(&adminRequest); err != nil {
 		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeInvalidRequest,
 			Message:      "Invalid request body",
 			MessageError: err.Error(),
 		})
@@ -56,7 +61,7 @@ func (h *Handler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary      Admin Login
-// @Description  Authenticates an admin user and returns a JWT token
+// @Description  Authenticates an admin user and returns a short lived JWT token with a refresh token
 // @Tags         Admin Domain
 // @Accept       json
 // @Produce      json
@@ -67,31 +72,101 @@ func (h *Handler) Register(c *gin.Context) {
 // @Failure      500      {object}  errors.ErrorDTO
 // @Router       /admin/login [post]
 func (h *Handler) Login(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	var adminRequest dto.AdminRequestDTO
 	if err := c.ShouldBindJSON(&adminRequest); err != nil {
 		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeInvalidRequest,
 			Message:      "Invalid request body",
 			MessageError: err.Error(),
 		})
 		return
 	}
 
-	token, err := h.adminController.Login(ctx, adminRequest)
+	tokens, err := h.adminController.Login(ctx, adminRequest)
 
 	if err != nil {
 		helper.HandleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, &TokenDTO{
-		TokenString: token,
-	})
+	c.JSON(http.StatusOK, toTokenDTO(tokens))
 }
 
+// TokenDTO keeps the access token in the token field of the responses before refresh tokens existed
 type TokenDTO struct {
-	TokenString string `json:"token"`
+	TokenString  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	// ExpiresIn is the lifetime of the access token, in seconds
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+func toTokenDTO(tokens authentity.TokenPair) *TokenDTO {
+	return &TokenDTO{
+		TokenString:  tokens.AccessToken.Token,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(tokens.AccessToken.ExpiresAt).Seconds()),
+	}
+}
+
+// Refresh godoc
+// @Summary      Refresh Admin Token
+// @Description  Exchanges a refresh token for new tokens. Each refresh token works once, reusing one revokes the session
+// @Tags         Admin Domain
+// @Accept       json
+// @Produce      json
+// @Param        request  body      dto.RefreshTokenRequestDTO  true  "Refresh token"
+// @Success      200      {object}  TokenDTO
+// @Failure      400      {object}  errors.ErrorDTO
+// @Failure      401      {object}  errors.ErrorDTO
+// @Failure      500      {object}  errors.ErrorDTO
+// @Router       /admin/token/refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	var request dto.RefreshTokenRequestDTO
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeInvalidRequest,
+			Message:      "Invalid request body",
+			MessageError: err.Error(),
+		})
+		return
+	}
+
+	tokens, err := h.adminController.Refresh(c.Request.Context(), request)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toTokenDTO(tokens))
+}
+
+// Logout godoc
+// @Summary      Admin Logout
+// @Description  Revokes the session of the token: its refresh token and every access token issued with it
+// @Tags         Admin Domain
+// @Security     BearerAuth
+// @Success      204
+// @Failure      401      {object}  errors.ErrorDTO
+// @Failure      500      {object}  errors.ErrorDTO
+// @Router       /admin/logout [post]
+func (h *Handler) Logout(c *gin.Context) {
+	value, _ := c.Get("claims")
+	claims, ok := value.(*authentity.CustomClaims)
+	if !ok {
+		helper.HandleError(c, &apperror.UnauthorizedError{Msg: "Token claims not found"})
+		return
+	}
+
+	if err := h.adminController.Logout(c.Request.Context(), claims); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
 }
 
 // ValidateToken godoc
@@ -122,7 +197,7 @@ func (h *Handler) ValidateToken(c *gin.Context) {
 	token := tokenParts[7:]
 
 	// Validate token using admin controller
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	isValid, adminData := h.adminController.ValidateToken(ctx, token)
 
 	if !isValid {
@@ -135,3 +210,191 @@ func (h *Handler) ValidateToken(c *gin.Context) {
 		"admin": adminData,
 	})
 }
+
+// List godoc
+// @Summary      List Admins
+// @Description  Lists the admin accounts, without their passwords
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200      {array}   dto.AdminResponseDTO
+// @Failure      401      {object}  errors.ErrorDTO
+// @Failure      500      {object}  errors.ErrorDTO
+// @Router       /admin/accounts [get]
+func (h *Handler) List(c *gin.Context) {
+	admins, err := h.adminController.List(c.Request.Context())
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, admins)
+}
+
+// FindByID godoc
+// @Summary      Get Admin
+// @Description  Gets an admin account by id
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id       path      string  true  "Admin ID"
+// @Success      200      {object}  dto.AdminResponseDTO
+// @Failure      401      {object}  errors.ErrorDTO
+// @Failure      404      {object}  errors.ErrorDTO
+// @Router       /admin/accounts/{id} [get]
+func (h *Handler) FindByID(c *gin.Context) {
+	admin, err := h.adminController.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, admin)
+}
+
+// Disable godoc
+// @Summary      Disable Admin
+// @Description  Disables an admin account. Disabled admins cannot log in and their tokens stop validating
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id       path      string  true  "Admin ID"
+// @Success      200      {object}  dto.AdminResponseDTO
+// @Failure      400      {object}  errors.ErrorDTO
+// @Failure      401      {object}  errors.ErrorDTO
+// @Failure      404      {object}  errors.ErrorDTO
+// @Router       /admin/accounts/{id}/disable [post]
+func (h *Handler) Disable(c *gin.Context) {
+	h.setDisabled(c, true)
+}
+
+// Enable godoc
+// @Summary      Enable Admin
+// @Description  Enables a disabled admin account again
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id       path      string  true  "Admin ID"
+// @Success      200      {object}  dto.AdminResponseDTO
+// @Failure      401      {object}  errors.ErrorDTO
+// @Failure      404      {object}  errors.ErrorDTO
+// @Router       /admin/accounts/{id}/enable [post]
+func (h *Handler) Enable(c *gin.Context) {
+	h.setDisabled(c, false)
+}
+
+func (h *Handler) setDisabled(c *gin.Context, disabled bool) {
+	admin, err := h.adminController.SetDisabled(c.Request.Context(), c.Param("id"), disabled, c.GetString("user_id"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, admin)
+}
+
+// SetRole godoc
+// @Summary      Change Admin Role
+// @Description  Changes the role of an admin account and revokes its sessions. The new permissions apply from the next login
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string               true  "Admin ID"
+// @Param        request  body      dto.RoleRequestDTO   true  "New role"
+// @Success      200      {object}  dto.AdminResponseDTO
+// @Failure      400      {object}  errors.ErrorDTO
+// @Failure      401      {object}  errors.ErrorDTO
+// @Failure      403      {object}  errors.ErrorDTO
+// @Failure      404      {object}  errors.ErrorDTO
+// @Router       /admin/accounts/{id}/role [put]
+func (h *Handler) SetRole(c *gin.Context) {
+	var request dto.RoleRequestDTO
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeInvalidRequest,
+			Message:      "Invalid request body",
+			MessageError: err.Error(),
+		})
+		return
+	}
+
+	admin, err := h.adminController.SetRole(c.Request.Context(), c.Param("id"), request, c.GetString("user_id"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, admin)
+}
+
+// ChangePassword godoc
+// @Summary      Change Own Password
+// @Description  Changes the password of the logged in admin, who must confirm the current one. Every session of the admin is revoked, this one included
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Param        request  body      dto.ChangePasswordRequestDTO  true  "Current and new password"
+// @Success      204
+// @Failure      400      {object}  errors.ErrorDTO
+// @Failure      401      {object}  errors.ErrorDTO
+// @Router       /admin/me/password [put]
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var request dto.ChangePasswordRequestDTO
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeInvalidRequest,
+			Message:      "Invalid request body",
+			MessageError: err.Error(),
+		})
+		return
+	}
+
+	if err := h.adminController.ChangePassword(c.Request.Context(), c.GetString("user_id"), request); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResetPassword godoc
+// @Summary      Reset Admin Password
+// @Description  Replaces the password of an admin with a temporary one, returned only in this response
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id       path      string  true  "Admin ID"
+// @Success      200      {object}  dto.PasswordResetResponseDTO
+// @Failure      401      {object}  errors.ErrorDTO
+// @Failure      404      {object}  errors.ErrorDTO
+// @Router       /admin/accounts/{id}/password/reset [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	reset, err := h.adminController.ResetPassword(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reset)
+}
+
+// Delete godoc
+// @Summary      Delete Admin
+// @Description  Deletes an admin account. The account is kept for auditing but can no longer be used
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        id       path      string  true  "Admin ID"
+// @Success      204
+// @Failure      400      {object}  errors.ErrorDTO
+// @Failure      401      {object}  errors.ErrorDTO
+// @Failure      404      {object}  errors.ErrorDTO
+// @Router       /admin/accounts/{id} [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	if err := h.adminController.Delete(c.Request.Context(), c.Param("id"), c.GetString("user_id")); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -2,13 +2,20 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/entity"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/gateway"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/utils"
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
 )
 
+// MinPasswordLength applies to the passwords chosen by admins
+const MinPasswordLength = 8
+
 type UseCases struct {
 	AdminGateway gateway.Gateway
 }
@@ -18,10 +25,16 @@ func Build(productGateway gateway.Gateway) *UseCases {
 }
 
 func (u *UseCases) Create(ctx context.Context, admin entity.Admin) error {
+	if !admin.Role.Valid() {
+		return invalidRoleError()
+	}
+	if len(admin.Password) < MinPasswordLength {
+		return &apperror.ValidationError{Msg: fmt.Sprintf("password must have at least %d characters", MinPasswordLength)}
+	}
 
 	saved, _ := u.FindByEmail(ctx, admin.Email)
 	if saved.Email != "" {
-		return &apperror.ValidationError{Msg: "User already registered"}
+		return &apperror.ConflictError{Msg: "User already registered"}
 	}
 
 	hash, err := utils.HashPassword(admin.Password)
@@ -51,16 +64,141 @@ func (u *UseCases) FindByEmail(ctx context.Context, email string) (entity.Admin,
 	return admin, nil
 }
 
-func (u *UseCases) Login(ctx context.Context, admin entity.Admin) (string, bool, error) {
+// Login returns the admin the credentials belong to, whose role the token is issued for
+func (u *UseCases) Login(ctx context.Context, admin entity.Admin) (entity.Admin, error) {
 
 	saved, err := u.FindByEmail(ctx, admin.Email)
+	var notFoundErr *apperror.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		utils.CheckDummyPassword(admin.Password)
+		return entity.Admin{}, &apperror.UnauthorizedError{Msg: "Invalid email or password"}
+	}
 	if err != nil {
-		return "", true, &apperror.UnauthorizedError{Msg: "Invalid email or password"}
+		return entity.Admin{}, err
 	}
 
 	if !utils.CheckPasswordHash(admin.Password, saved.Password) {
-		return "", true, &apperror.UnauthorizedError{Msg: "Invalid email or password"}
+		return entity.Admin{}, &apperror.UnauthorizedError{Msg: "Invalid email or password"}
+	}
+
+	// Checked after the password, so the account status is only told to whoever knows it
+	if saved.Disabled {
+		return entity.Admin{}, &apperror.UnauthorizedError{Msg: "Admin account is disabled"}
+	}
+
+	return saved, nil
+}
+
+func (u *UseCases) FindByID(ctx context.Context, id string) (entity.Admin, error) {
+	return u.AdminGateway.FindByID(ctx, id)
+}
+
+func (u *UseCases) List(ctx context.Context) ([]entity.Admin, error) {
+	return u.AdminGateway.List(ctx)
+}
+
+// SetDisabled disables or enables the admin. Admins cannot disable themselves, so the last enabled
+// admin cannot lock everyone out.
+func (u *UseCases) SetDisabled(ctx context.Context, id string, disabled bool, actorID string) (entity.Admin, error) {
+	if disabled && id == actorID {
+		return entity.Admin{}, &apperror.ValidationError{Msg: "admins cannot disable their own account"}
+	}
+
+	admin, err := u.AdminGateway.FindByID(ctx, id)
+	if err != nil {
+		return entity.Admin{}, err
+	}
+
+	updated := admin.WithDisabled(disabled)
+	if err := u.AdminGateway.Update(ctx, updated); err != nil {
+		return entity.Admin{}, err
+	}
+
+	return updated, nil
+}
+
+// SetRole changes the role of the admin. Like disabling, admins cannot change their own role, so the
+// last admin cannot demote themselves.
+func (u *UseCases) SetRole(ctx context.Context, id string, role authentity.Role, actorID string) (entity.Admin, error) {
+	if !role.Valid() {
+		return entity.Admin{}, invalidRoleError()
+	}
+	if id == actorID {
+		return entity.Admin{}, &apperror.ValidationError{Msg: "admins cannot change their own role"}
+	}
+
+	admin, err := u.AdminGateway.FindByID(ctx, id)
+	if err != nil {
+		return entity.Admin{}, err
+	}
+
+	updated := admin.WithRole(role)
+	if err := u.AdminGateway.Update(ctx, updated); err != nil {
+		return entity.Admin{}, err
+	}
+
+	return updated, nil
+}
+
+// ChangePassword replaces the password of the admin, who must know the current one
+func (u *UseCases) ChangePassword(ctx context.Context, id string, currentPassword string, newPassword string) error {
+	if len(newPassword) < MinPasswordLength {
+		return &apperror.ValidationError{Msg: fmt.Sprintf("new password must have at least %d characters", MinPasswordLength)}
+	}
+
+	admin, err := u.AdminGateway.FindByID(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	return saved.Id, true, nil
+	if !utils.CheckPasswordHash(currentPassword, admin.Password) {
+		return &apperror.ValidationError{Msg: "current password is incorrect"}
+	}
+
+	return u.updatePassword(ctx, admin, newPassword)
+}
+
+// ResetPassword replaces the password of another admin with a random one, returned to be handed over
+func (u *UseCases) ResetPassword(ctx context.Context, id string) (string, error) {
+	admin, err := u.AdminGateway.FindByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	password, err := utils.GeneratePassword()
+	if err != nil {
+		return "", &apperror.InternalError{Msg: err.Error()}
+	}
+
+	if err := u.updatePassword(ctx, admin, password); err != nil {
+		return "", err
+	}
+
+	return password, nil
+}
+
+// Delete soft deletes the admin. Like disabling, admins cannot delete themselves.
+func (u *UseCases) Delete(ctx context.Context, id string, actorID string) error {
+	if id == actorID {
+		return &apperror.ValidationError{Msg: "admins cannot delete their own account"}
+	}
+
+	return u.AdminGateway.Delete(ctx, id)
+}
+
+func (u *UseCases) updatePassword(ctx context.Context, admin entity.Admin, password string) error {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return &apperror.InternalError{Msg: err.Error()}
+	}
+
+	return u.AdminGateway.Update(ctx, admin.WithPassword(hash))
+}
+
+func invalidRoleError() error {
+	roles := make([]string, 0, len(authentity.Roles()))
+	for _, role := range authentity.Roles() {
+		roles = append(roles, string(role))
+	}
+	return &apperror.ValidationError{Msg: "role must be one of " + strings.Join(roles, ", ")}
 }
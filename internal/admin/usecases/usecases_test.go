@@ -0,0 +1,194 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/dto"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/gateway"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/admin/utils"
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+)
+
+// fakeDataSource keeps the admins in memory, by id
+type fakeDataSource map[string]dto.AdminDAO
+
+func (f fakeDataSource) Create(_ context.Context, admin dto.AdminDAO) error {
+	f[admin.ID] = admin
+	return nil
+}
+
+func (f fakeDataSource) FindByEmail(_ context.Context, email string) (dto.AdminDAO, error) {
+	for _, admin := range f {
+		if admin.Email == email {
+			return admin, nil
+		}
+	}
+	return dto.AdminDAO{}, gorm.ErrRecordNotFound
+}
+
+func (f fakeDataSource) FindByID(_ context.Context, id string) (dto.AdminDAO, error) {
+	admin, ok := f[id]
+	if !ok {
+		return dto.AdminDAO{}, gorm.ErrRecordNotFound
+	}
+	return admin, nil
+}
+
+func (f fakeDataSource) List(context.Context) ([]dto.AdminDAO, error) {
+	admins := make([]dto.AdminDAO, 0, len(f))
+	for _, admin := range f {
+		admins = append(admins, admin)
+	}
+	return admins, nil
+}
+
+func (f fakeDataSource) Update(_ context.Context, admin dto.AdminDAO) error {
+	if _, ok := f[admin.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	f[admin.ID] = admin
+	return nil
+}
+
+func (f fakeDataSource) Delete(_ context.Context, id string) error {
+	if _, ok := f[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(f, id)
+	return nil
+}
+
+func newUseCases(t *testing.T, admins ...entity.Admin) *UseCases {
+	t.Helper()
+	datasource := fakeDataSource{}
+	for _, admin := range admins {
+		datasource[admin.Id] = dto.ToAdminDAO(admin)
+	}
+	return Build(*gateway.Build(datasource))
+}
+
+func TestUseCases_Login(t *testing.T) {
+	hash, err := utils.HashPassword("kitchen-secret")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		admin    entity.Admin
+		email    string
+		password string
+		wantErr  string
+	}{
+		{
+			name:     "valid credentials",
+			admin:    entity.Admin{Id: "1", Email: "manager@golunch.com", Password: hash, Role: authentity.RoleManager},
+			password: "kitchen-secret",
+		},
+		{
+			name:     "wrong password",
+			admin:    entity.Admin{Id: "1", Email: "manager@golunch.com", Password: hash},
+			password: "guess",
+			wantErr:  "Invalid email or password",
+		},
+		{
+			name:     "unknown email",
+			admin:    entity.Admin{Id: "1", Email: "manager@golunch.com", Password: hash},
+			email:    "nobody@golunch.com",
+			password: "kitchen-secret",
+			wantErr:  "Invalid email or password",
+		},
+		{
+			name:     "disabled admin",
+			admin:    entity.Admin{Id: "1", Email: "manager@golunch.com", Password: hash, Disabled: true},
+			password: "kitchen-secret",
+			wantErr:  "Admin account is disabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCases := newUseCases(t, tt.admin)
+			email := tt.admin.Email
+			if tt.email != "" {
+				email = tt.email
+			}
+
+			admin, err := useCases.Login(context.Background(), entity.Admin{Email: email, Password: tt.password})
+
+			if tt.wantErr != "" {
+				assert.IsType(t, &apperror.UnauthorizedError{}, err)
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.admin.Id, admin.Id)
+			assert.Equal(t, tt.admin.Role, admin.Role)
+		})
+	}
+}
+
+func TestUseCases_AdminsCannotLockThemselvesOut(t *testing.T) {
+	useCases := newUseCases(t, entity.Admin{Id: "1", Email: "manager@golunch.com"})
+
+	_, err := useCases.SetDisabled(context.Background(), "1", true, "1")
+	assert.IsType(t, &apperror.ValidationError{}, err)
+
+	err = useCases.Delete(context.Background(), "1", "1")
+	assert.IsType(t, &apperror.ValidationError{}, err)
+
+	_, err = useCases.SetDisabled(context.Background(), "2", true, "1")
+	assert.IsType(t, &apperror.NotFoundError{}, err)
+}
+
+func TestUseCases_SetDisabled_ReturnsSavedAdmin(t *testing.T) {
+	lastUpdate := time.Now().Add(-time.Hour)
+	useCases := newUseCases(t, entity.Admin{Id: "1", Email: "cook@golunch.com", CreatedAt: lastUpdate, UpdatedAt: lastUpdate})
+
+	disabled, err := useCases.SetDisabled(context.Background(), "1", true, "2")
+	assert.NoError(t, err)
+
+	saved, err := useCases.FindByID(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.True(t, saved.Disabled)
+	assert.True(t, saved.UpdatedAt.After(lastUpdate))
+	assert.Equal(t, saved.UpdatedAt, disabled.UpdatedAt)
+}
+
+func TestUseCases_ChangePassword(t *testing.T) {
+	hash, err := utils.HashPassword("kitchen-secret")
+	assert.NoError(t, err)
+	useCases := newUseCases(t, entity.Admin{Id: "1", Email: "manager@golunch.com", Password: hash})
+
+	err = useCases.ChangePassword(context.Background(), "1", "kitchen-secret", "short")
+	assert.EqualError(t, err, "new password must have at least 8 characters")
+
+	err = useCases.ChangePassword(context.Background(), "1", "wrong-secret", "new-kitchen-secret")
+	assert.EqualError(t, err, "current password is incorrect")
+}
+
+func TestUseCases_Roles(t *testing.T) {
+	useCases := newUseCases(t,
+		entity.Admin{Id: "1", Email: "manager@golunch.com", Role: authentity.RoleAdmin},
+		entity.Admin{Id: "2", Email: "tablet@golunch.com", Role: authentity.RoleKitchenStaff},
+	)
+
+	err := useCases.Create(context.Background(), entity.Admin{Email: "chef@golunch.com", Password: "kitchen-secret", Role: "chef"})
+	assert.EqualError(t, err, "role must be one of kitchen_staff, cashier, manager, admin")
+
+	_, err = useCases.SetRole(context.Background(), "1", authentity.RoleCashier, "1")
+	assert.EqualError(t, err, "admins cannot change their own role")
+
+	admin, err := useCases.SetRole(context.Background(), "2", authentity.RoleCashier, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, authentity.RoleCashier, admin.Role)
+
+	saved, err := useCases.FindByID(context.Background(), "2")
+	assert.NoError(t, err)
+	assert.Equal(t, authentity.RoleCashier, saved.Role)
+}
@@ -1,9 +1,22 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// temporaryPasswordBytes of randomness give 22 characters once encoded
+const temporaryPasswordBytes = 16
+
+// dummyHash is compared against when there is no account, hashed with the same cost as real passwords
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("no account has this password")
+	return hash
+})
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	return string(bytes), err
@@ -13,3 +26,18 @@ func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// CheckDummyPassword takes as long as CheckPasswordHash and never matches, so a login for an unknown
+// account cannot be told apart by its response time
+func CheckDummyPassword(password string) {
+	CheckPasswordHash(password, dummyHash())
+}
+
+// GeneratePassword creates a random password for accounts whose password was reset
+func GeneratePassword() (string, error) {
+	bytes := make([]byte, temporaryPasswordBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestHashPassword(t *testing.T) {
@@ -66,3 +68,17 @@ func TestCheckPasswordHash(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckDummyPassword(t *testing.T) {
+	hash, err := HashPassword("mypassword")
+	if err != nil {
+		t.Fatalf("Failed to hash password for tests: %v", err)
+	}
+
+	// Comparing costs as much as for a real account only if both hashes have the same cost
+	wantCost, _ := bcrypt.Cost([]byte(hash))
+	gotCost, err := bcrypt.Cost([]byte(dummyHash()))
+	if err != nil || gotCost != wantCost {
+		t.Errorf("dummy hash cost = %d (%v), want %d", gotCost, err, wantCost)
+	}
+}
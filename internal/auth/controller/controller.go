@@ -1,27 +1,74 @@
 package controller
 
 import (
+	"context"
+	"time"
+
 	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/gateway"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/usecase"
 )
 
 type Controller struct {
-	generateTokenUC *usecase.GenerateTokenUseCase
-	validateTokenUC *usecase.ValidateTokenUseCase
+	generateTokenUC      *usecase.GenerateTokenUseCase
+	getJWKSUC            *usecase.GetJWKSUseCase
+	issueTokensUC        *usecase.IssueTokensUseCase
+	rotateRefreshTokenUC *usecase.RotateRefreshTokenUseCase
+	revokeSessionUC      *usecase.RevokeSessionUseCase
+	validateTokenUC      *usecase.ValidateTokenUseCase
 }
 
-func New(tokenGateway gateway.TokenGateway) *Controller {
+func New(tokenGateway gateway.TokenGateway, sessionGateway gateway.SessionGateway, refreshExpiry time.Duration) *Controller {
 	return &Controller{
-		generateTokenUC: usecase.NewGenerateTokenUseCase(tokenGateway),
-		validateTokenUC: usecase.NewValidateTokenUseCase(tokenGateway),
+		generateTokenUC:      usecase.NewGenerateTokenUseCase(tokenGateway),
+		getJWKSUC:            usecase.NewGetJWKSUseCase(tokenGateway),
+		issueTokensUC:        usecase.NewIssueTokensUseCase(tokenGateway, sessionGateway, refreshExpiry),
+		rotateRefreshTokenUC: usecase.NewRotateRefreshTokenUseCase(tokenGateway, sessionGateway, refreshExpiry),
+		revokeSessionUC:      usecase.NewRevokeSessionUseCase(sessionGateway),
+		validateTokenUC:      usecase.NewValidateTokenUseCase(tokenGateway, sessionGateway),
 	}
 }
 
-func (c *Controller) GenerateToken(userID, userType string, additionalClaims map[string]any) (string, error) {
-	return c.generateTokenUC.Execute(userID, userType, additionalClaims)
+// GenerateToken issues a token embedding the role and its permissions. Users without a role, such as
+// customers, pass an empty one.
+func (c *Controller) GenerateToken(userID, userType string, role entity.Role, additionalClaims map[string]any) (entity.AccessToken, error) {
+	return c.generateTokenUC.Execute(userID, userType, role, additionalClaims)
+}
+
+// IssueTokens starts a session, or adds tokens to the session familyID
+func (c *Controller) IssueTokens(ctx context.Context, userID, userType string, role entity.Role, familyID string) (entity.TokenPair, error) {
+	return c.issueTokensUC.Execute(ctx, userID, userType, role, familyID)
+}
+
+// CheckRefreshToken returns the session of a refresh token that can be rotated, see
+// usecase.RotateRefreshTokenUseCase
+func (c *Controller) CheckRefreshToken(ctx context.Context, refreshToken string) (entity.RefreshToken, error) {
+	return c.rotateRefreshTokenUC.Check(ctx, refreshToken)
+}
+
+// RotateRefreshToken consumes the checked refresh token and issues the next tokens of its session
+func (c *Controller) RotateRefreshToken(ctx context.Context, used entity.RefreshToken, role entity.Role) (entity.TokenPair, error) {
+	return c.rotateRefreshTokenUC.Execute(ctx, used, role)
+}
+
+// Logout revokes the session of the token
+func (c *Controller) Logout(ctx context.Context, claims *entity.CustomClaims) error {
+	return c.revokeSessionUC.Execute(ctx, claims)
+}
+
+func (c *Controller) RevokeSession(ctx context.Context, familyID string) error {
+	return c.revokeSessionUC.Family(ctx, familyID)
+}
+
+func (c *Controller) RevokeUserSessions(ctx context.Context, userID string) error {
+	return c.revokeSessionUC.User(ctx, userID)
+}
+
+func (c *Controller) ValidateToken(ctx context.Context, tokenString string) (*entity.CustomClaims, error) {
+	return c.validateTokenUC.Execute(ctx, tokenString)
 }
 
-func (c *Controller) ValidateToken(tokenString string) (*entity.CustomClaims, error) {
-	return c.validateTokenUC.Execute(tokenString)
+// JWKS returns the public keys other services verify the tokens with
+func (c *Controller) JWKS() entity.JSONWebKeySet {
+	return c.getJWKSUC.Execute()
 }
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	gormEntity "github.com/fiap-161/tc-golunch-operation-service/internal/shared/entity"
+)
+
+// RefreshTokenDAO keeps only the hash of the token, a leaked table cannot be used to refresh
+type RefreshTokenDAO struct {
+	gormEntity.Entity
+	FamilyID        string    `gorm:"type:uuid;index;not null"`
+	UserID          string    `gorm:"index;not null"`
+	UserType        string    `gorm:"not null"`
+	TokenHash       string    `gorm:"uniqueIndex;not null"`
+	AccessTokenID   string    `gorm:"not null"`
+	AccessExpiresAt time.Time `gorm:"not null"`
+	ExpiresAt       time.Time `gorm:"not null"`
+	UsedAt          *time.Time
+	RevokedAt       *time.Time
+}
+
+// RevokedAccessTokenDAO is a denylist entry, by jti, kept until the token expires
+type RevokedAccessTokenDAO struct {
+	ID        string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+func ToRefreshTokenDAO(token entity.RefreshToken) RefreshTokenDAO {
+	return RefreshTokenDAO{
+		Entity: gormEntity.Entity{
+			ID:        token.ID,
+			CreatedAt: token.CreatedAt,
+			UpdatedAt: token.CreatedAt,
+		},
+		FamilyID:        token.FamilyID,
+		UserID:          token.UserID,
+		UserType:        token.UserType,
+		TokenHash:       token.TokenHash,
+		AccessTokenID:   token.AccessTokenID,
+		AccessExpiresAt: token.AccessExpiresAt,
+		ExpiresAt:       token.ExpiresAt,
+		UsedAt:          token.UsedAt,
+		RevokedAt:       token.RevokedAt,
+	}
+}
+
+func FromRefreshTokenDAO(dao RefreshTokenDAO) entity.RefreshToken {
+	return entity.RefreshToken{
+		ID:              dao.ID,
+		FamilyID:        dao.FamilyID,
+		UserID:          dao.UserID,
+		UserType:        dao.UserType,
+		TokenHash:       dao.TokenHash,
+		AccessTokenID:   dao.AccessTokenID,
+		AccessExpiresAt: dao.AccessExpiresAt,
+		ExpiresAt:       dao.ExpiresAt,
+		UsedAt:          dao.UsedAt,
+		RevokedAt:       dao.RevokedAt,
+		CreatedAt:       dao.CreatedAt,
+	}
+}
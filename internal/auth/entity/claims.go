@@ -1,10 +1,38 @@
 package entity
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type CustomClaims struct {
 	jwt.RegisteredClaims
-	UserID   string         `json:"user_id"`
-	UserType string         `json:"user_type"`
-	Custom   map[string]any `json:"custom"`
+	UserID      string       `json:"user_id"`
+	UserType    string       `json:"user_type"`
+	Role        Role         `json:"role,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty"`
+	// SessionID is the refresh token family the token was issued with, revoked on logout
+	SessionID string         `json:"sid,omitempty"`
+	Custom    map[string]any `json:"custom"`
+}
+
+// Validate is called by the jwt parser after the registered claims, whose issuer and audience are
+// checked by the parser options
+func (c *CustomClaims) Validate() error {
+	if c.UserID == "" || c.UserType == "" {
+		return errors.New("user_id and user_type are required")
+	}
+	if c.Subject != "" && c.Subject != c.UserID {
+		return errors.New("sub does not match user_id")
+	}
+	if c.Role != "" && !c.Role.Valid() {
+		return errors.New("unknown role")
+	}
+	return nil
+}
+
+// GrantedPermissions returns the permissions the token grants, see StaffRole and GrantedPermissions
+func (c *CustomClaims) GrantedPermissions(defaultAdminRole Role) []Permission {
+	return GrantedPermissions(StaffRole(c.UserType, c.Role, defaultAdminRole), c.Permissions)
 }
@@ -0,0 +1,75 @@
+package entity
+
+import "slices"
+
+// Role of a staff account. Each role grants a fixed set of permissions.
+type Role string
+
+const (
+	RoleAdmin        Role = "admin"
+	RoleManager      Role = "manager"
+	RoleCashier      Role = "cashier"
+	RoleKitchenStaff Role = "kitchen_staff"
+)
+
+// Permission to perform an action, named resource:action
+type Permission string
+
+const (
+	PermissionOrdersRead       Permission = "orders:read"
+	PermissionOrdersAdvance    Permission = "orders:advance"
+	PermissionOrdersCancel     Permission = "orders:cancel"
+	PermissionReportsRead      Permission = "reports:read"
+	PermissionOperationsManage Permission = "operations:manage"
+	PermissionAccountsManage   Permission = "accounts:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleKitchenStaff: {PermissionOrdersRead, PermissionOrdersAdvance},
+	RoleCashier:      {PermissionOrdersRead, PermissionOrdersCancel},
+	RoleManager: {
+		PermissionOrdersRead, PermissionOrdersAdvance, PermissionOrdersCancel,
+		PermissionReportsRead, PermissionOperationsManage,
+	},
+	RoleAdmin: {
+		PermissionOrdersRead, PermissionOrdersAdvance, PermissionOrdersCancel,
+		PermissionReportsRead, PermissionOperationsManage, PermissionAccountsManage,
+	},
+}
+
+// Roles lists the valid roles, from the least to the most privileged
+func Roles() []Role {
+	return []Role{RoleKitchenStaff, RoleCashier, RoleManager, RoleAdmin}
+}
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Permissions returns the permissions granted by the role, none for unknown roles
+func (r Role) Permissions() []Permission {
+	return append([]Permission(nil), rolePermissions[r]...)
+}
+
+// StaffRole returns the role a token acts with. Admin tokens without a role, such as those of the central
+// auth service, act with defaultAdminRole, which may be empty to grant them nothing.
+func StaffRole(userType string, role Role, defaultAdminRole Role) Role {
+	if role == "" && userType == string(RoleAdmin) {
+		return defaultAdminRole
+	}
+	return role
+}
+
+// GrantedPermissions returns the permissions granted to a token acting with role. The role bounds what is
+// granted: a token carrying permissions only gets those its role grants, and a token carrying none gets
+// all of them. Without a role, or with an unknown one, nothing is granted.
+func GrantedPermissions(role Role, permissions []Permission) []Permission {
+	granted := role.Permissions()
+	if len(permissions) == 0 {
+		return granted
+	}
+	return slices.DeleteFunc(granted, func(permission Permission) bool {
+		return !slices.Contains(permissions, permission)
+	})
+}
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// AccessToken is a signed access token with what is needed to revoke it before it expires
+type AccessToken struct {
+	Token     string
+	ID        string
+	ExpiresAt time.Time
+}
+
+// TokenPair is issued on login and on every refresh. The refresh token is only shown to the client,
+// the server keeps its hash.
+type TokenPair struct {
+	AccessToken  AccessToken
+	RefreshToken string
+}
+
+// RefreshToken is a server side record of a refresh token. Every refresh replaces the token with a new one
+// of the same family, which is the login session, and using a replaced token again revokes the family.
+type RefreshToken struct {
+	ID        string
+	FamilyID  string
+	UserID    string
+	UserType  string
+	TokenHash string
+	// AccessTokenID and AccessExpiresAt identify the access token issued with it, denied when the family
+	// is revoked
+	AccessTokenID   string
+	AccessExpiresAt time.Time
+	ExpiresAt       time.Time
+	UsedAt          *time.Time
+	RevokedAt       *time.Time
+	CreatedAt       time.Time
+}
+
+func (r RefreshToken) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
@@ -6,43 +6,94 @@ import (
 	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/gateway"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type JWTService struct {
-	secretKey      string
+	keys           KeySource
 	expiryDuration time.Duration
+	issuer         string
+	audience       string
+	parser         *jwt.Parser
 }
 
-func NewJWTService(secretKey string, expiryDuration time.Duration) *JWTService {
+// NewJWTService signs and verifies with keys. The issuer and audience, when not empty, are set in the
+// issued tokens and required from the validated ones.
+func NewJWTService(keys KeySource, expiryDuration time.Duration, issuer, audience string) *JWTService {
+	// Only the algorithm of the keys is accepted, so a token cannot pick how it is verified
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{keys.Method().Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
 	return &JWTService{
-		secretKey:      secretKey,
+		keys:           keys,
 		expiryDuration: expiryDuration,
+		issuer:         issuer,
+		audience:       audience,
+		parser:         jwt.NewParser(options...),
 	}
 }
 
 var _ gateway.TokenGateway = (*JWTService)(nil)
 
-func (s *JWTService) GenerateToken(userID, userType string, additionalClaims map[string]any) (string, error) {
+func (s *JWTService) GenerateToken(userID, userType string, role entity.Role, sessionID string, additionalClaims map[string]any) (entity.AccessToken, error) {
 	now := time.Now()
+	accessToken := entity.AccessToken{
+		ID:        uuid.NewString(),
+		ExpiresAt: now.Add(s.expiryDuration),
+	}
 
 	claims := entity.CustomClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.expiryDuration)),
+			// The jti identifies the token in the denylist
+			ID:        accessToken.ID,
+			Issuer:    s.issuer,
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(accessToken.ExpiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
-		UserID:   userID,
-		UserType: userType,
-		Custom:   additionalClaims,
+		UserID:      userID,
+		UserType:    userType,
+		Role:        role,
+		Permissions: role.Permissions(),
+		SessionID:   sessionID,
+		Custom:      additionalClaims,
+	}
+	if s.audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.audience}
+	}
+
+	kid, key, err := s.keys.SigningKey(now)
+	if err != nil {
+		return entity.AccessToken{}, err
+	}
+
+	token := jwt.NewWithClaims(s.keys.Method(), claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return entity.AccessToken{}, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secretKey))
+	accessToken.Token = signed
+	return accessToken, nil
 }
 
 func (s *JWTService) ValidateToken(tokenString string) (*entity.CustomClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &entity.CustomClaims{}, func(token *jwt.Token) (any, error) {
-		return []byte(s.secretKey), nil
+	token, err := s.parser.ParseWithClaims(tokenString, &entity.CustomClaims{}, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.keys.VerificationKey(kid, time.Now())
 	})
 	if err != nil {
 		return nil, err
@@ -54,3 +105,8 @@ func (s *JWTService) ValidateToken(tokenString string) (*entity.CustomClaims, er
 
 	return nil, jwt.ErrSignatureInvalid
 }
+
+// JWKS returns the public keys other services verify the tokens with
+func (s *JWTService) JWKS() entity.JSONWebKeySet {
+	return s.keys.JWKS(time.Now())
+}
@@ -0,0 +1,133 @@
+package external
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+)
+
+func TestLoadKeyRing_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	for _, kid := range []string{"2026-09", "2026-10"} {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	}
+	manifest := `{"keys": [
+		{"kid": "2026-10", "file": "2026-10.pem", "active_from": "2026-10-01T00:00:00Z"},
+		{"kid": "2026-09", "file": "2026-09.pem", "active_from": "2026-09-01T00:00:00Z"}
+	]}`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, KeyManifest), []byte(manifest), 0o600))
+
+	keyRing, err := LoadKeyRing("ES256", dir, 15*time.Minute)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		signingKid  string
+		publishedTo []string
+	}{
+		{
+			name:        "the next key is published before it signs",
+			now:         time.Date(2026, 9, 20, 0, 0, 0, 0, time.UTC),
+			signingKid:  "2026-09",
+			publishedTo: []string{"2026-09", "2026-10"},
+		},
+		{
+			name:        "the previous key verifies during the overlap",
+			now:         time.Date(2026, 10, 1, 0, 10, 0, 0, time.UTC),
+			signingKid:  "2026-10",
+			publishedTo: []string{"2026-09", "2026-10"},
+		},
+		{
+			name:        "the previous key retires after the overlap",
+			now:         time.Date(2026, 10, 1, 0, 15, 0, 0, time.UTC),
+			signingKid:  "2026-10",
+			publishedTo: []string{"2026-10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kid, _, err := keyRing.SigningKey(tt.now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.signingKid, kid)
+
+			var published []string
+			for _, jwk := range keyRing.JWKS(tt.now).Keys {
+				assert.Equal(t, "EC", jwk.KeyType)
+				assert.Equal(t, "P-256", jwk.Curve)
+				assert.Len(t, jwk.X, 43)
+				published = append(published, jwk.KeyID)
+			}
+			assert.Equal(t, tt.publishedTo, published)
+
+			_, err = keyRing.VerificationKey("2026-09", tt.now)
+			assert.Equal(t, len(tt.publishedTo) == 2, err == nil)
+		})
+	}
+}
+
+func TestJWTService_ValidateToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	keyRing, err := NewKeyRing(jwt.SigningMethodRS256, []SigningKey{{ID: "current", Private: key}}, time.Minute)
+	assert.NoError(t, err)
+	service := NewJWTService(keyRing, time.Minute, "tc-golunch-operation-service", "tc-golunch")
+
+	valid, err := service.GenerateToken("1", "admin", entity.RoleManager, "", nil)
+	assert.NoError(t, err)
+	otherAudience, err := NewJWTService(keyRing, time.Minute, "tc-golunch-operation-service", "payments").
+		GenerateToken("1", "admin", entity.RoleManager, "", nil)
+	assert.NoError(t, err)
+
+	// Signing with the public key as an HMAC secret must not pass for RS256
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, entity.CustomClaims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		UserID:           "1",
+		UserType:         "admin",
+	})
+	confused.Header["kid"] = "current"
+	algorithmConfusion, err := confused.SignedString(publicKey)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid token", token: valid.Token},
+		{name: "other audience", token: otherAudience.Token, wantErr: true},
+		{name: "algorithm confusion", token: algorithmConfusion, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := service.ValidateToken(tt.token)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, entity.RoleManager, claims.Role)
+		})
+	}
+}
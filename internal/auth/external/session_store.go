@@ -0,0 +1,170 @@
+package external
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/dto"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/gateway"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+)
+
+type DB interface {
+	WithContext(ctx context.Context) *gorm.DB
+}
+
+// SessionStore keeps the refresh tokens and the access token denylist in the database, shared by
+// every replica
+type SessionStore struct {
+	db DB
+}
+
+func NewSessionStore(db DB) *SessionStore {
+	return &SessionStore{
+		db: db,
+	}
+}
+
+var _ gateway.SessionGateway = (*SessionStore)(nil)
+
+// CreateRefreshToken also purges the expired refresh tokens of the user, so the table does not grow
+// with every refresh
+func (s *SessionStore) CreateRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createRefreshToken(tx, token)
+	})
+	if err != nil {
+		return apperror.FromStorage(err, "refresh token not found", "")
+	}
+
+	return nil
+}
+
+func (s *SessionStore) FindRefreshToken(ctx context.Context, tokenHash string) (entity.RefreshToken, error) {
+	var dao dto.RefreshTokenDAO
+
+	if err := s.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&dao).Error; err != nil {
+		return entity.RefreshToken{}, apperror.FromStorage(err, "refresh token not found", "")
+	}
+
+	return dto.FromRefreshTokenDAO(dao), nil
+}
+
+// RotateRefreshToken marks the used token only while it is neither used nor revoked. A revoked family
+// has every token revoked, so checking the used token is enough. The update keeps the token row locked
+// until the next token is stored, and revoke locks the rows of the family first, so a revocation
+// either stops the rotation or also revokes the next token.
+func (s *SessionStore) RotateRefreshToken(ctx context.Context, usedID string, next entity.RefreshToken) (bool, error) {
+	rotated := false
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&dto.RefreshTokenDAO{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", usedID).
+			Updates(map[string]any{"used_at": now, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		rotated = true
+		return createRefreshToken(tx, next)
+	})
+	if err != nil {
+		return false, apperror.FromStorage(err, "refresh token not found", "")
+	}
+
+	return rotated, nil
+}
+
+func createRefreshToken(tx *gorm.DB, token entity.RefreshToken) error {
+	if err := tx.Where("user_id = ? AND expires_at <= ?", token.UserID, time.Now()).Delete(&dto.RefreshTokenDAO{}).Error; err != nil {
+		return err
+	}
+
+	dao := dto.ToRefreshTokenDAO(token)
+	return tx.Create(&dao).Error
+}
+
+func (s *SessionStore) RevokeFamily(ctx context.Context, familyID string) error {
+	return s.revoke(ctx, "family_id = ?", familyID)
+}
+
+func (s *SessionStore) RevokeUser(ctx context.Context, userID string) error {
+	return s.revoke(ctx, "user_id = ?", userID)
+}
+
+func (s *SessionStore) DenyAccessToken(ctx context.Context, id string, expiresAt time.Time) error {
+	denied := dto.RevokedAccessTokenDAO{ID: id, ExpiresAt: expiresAt}
+
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&denied).Error; err != nil {
+		return apperror.FromStorage(err, "refresh token not found", "")
+	}
+
+	return nil
+}
+
+func (s *SessionStore) IsAccessTokenDenied(ctx context.Context, id string) (bool, error) {
+	var count int64
+
+	err := s.db.WithContext(ctx).
+		Model(&dto.RevokedAccessTokenDAO{}).
+		Where("id = ? AND expires_at > ?", id, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, apperror.FromStorage(err, "refresh token not found", "")
+	}
+
+	return count > 0, nil
+}
+
+// revoke revokes the refresh tokens matching the condition and denies the access tokens issued with
+// them that have not expired yet. Denylist entries past their expiry are purged on the way.
+func (s *SessionStore) revoke(ctx context.Context, query string, args ...any) error {
+	now := time.Now()
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Waits for the rotations in progress, so the tokens they store are read and revoked below
+		var locked []string
+		lock := tx.Model(&dto.RefreshTokenDAO{}).Clauses(clause.Locking{Strength: "UPDATE"})
+		if err := lock.Where(query, args...).Where("revoked_at IS NULL").Pluck("id", &locked).Error; err != nil {
+			return err
+		}
+
+		var tokens []dto.RefreshTokenDAO
+		if err := tx.Where(query, args...).Where("access_expires_at > ?", now).Find(&tokens).Error; err != nil {
+			return err
+		}
+
+		denied := make([]dto.RevokedAccessTokenDAO, 0, len(tokens))
+		for _, token := range tokens {
+			denied = append(denied, dto.RevokedAccessTokenDAO{ID: token.AccessTokenID, ExpiresAt: token.AccessExpiresAt})
+		}
+		if len(denied) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&denied).Error; err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&dto.RefreshTokenDAO{}).
+			Where(query, args...).
+			Where("revoked_at IS NULL").
+			Updates(map[string]any{"revoked_at": now, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("expires_at <= ?", now).Delete(&dto.RevokedAccessTokenDAO{}).Error
+	})
+	if err != nil {
+		return apperror.FromStorage(err, "refresh token not found", "")
+	}
+
+	return nil
+}
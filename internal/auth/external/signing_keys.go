@@ -0,0 +1,264 @@
+package external
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+)
+
+// KeyManifest is the name of the file listing the keys of a key directory
+const KeyManifest = "keys.json"
+
+// minRSAKeyBits is the smallest RSA key accepted for RS256
+const minRSAKeyBits = 2048
+
+// KeySource provides the keys tokens are signed and verified with
+type KeySource interface {
+	Method() jwt.SigningMethod
+	// SigningKey returns the key to sign with at now and its kid, empty for keys without one
+	SigningKey(now time.Time) (kid string, key any, err error)
+	// VerificationKey returns the key for the kid of a token at now
+	VerificationKey(kid string, now time.Time) (any, error)
+	// JWKS returns the public keys to publish at now
+	JWKS(now time.Time) entity.JSONWebKeySet
+}
+
+// hmacKey is the shared secret of HS256. Its tokens carry no kid and it is never published.
+type hmacKey []byte
+
+func NewHMACKey(secret string) KeySource {
+	return hmacKey(secret)
+}
+
+func (k hmacKey) Method() jwt.SigningMethod {
+	return jwt.SigningMethodHS256
+}
+
+func (k hmacKey) SigningKey(time.Time) (string, any, error) {
+	return "", []byte(k), nil
+}
+
+func (k hmacKey) VerificationKey(string, time.Time) (any, error) {
+	return []byte(k), nil
+}
+
+func (k hmacKey) JWKS(time.Time) entity.JSONWebKeySet {
+	return entity.JSONWebKeySet{Keys: []entity.JSONWebKey{}}
+}
+
+// SigningKey is a private key of a KeyRing, used to sign from ActiveFrom until the next key activates
+type SigningKey struct {
+	ID         string
+	ActiveFrom time.Time
+	Private    crypto.Signer
+}
+
+// KeyRing rotates the signing keys on schedule. Each key signs from its activation until the next key
+// activates, and keeps verifying for the overlap after that, so the tokens it signed last can expire.
+// Keys are published in the JWKS before they activate, so verifiers caching the JWKS already know them.
+type KeyRing struct {
+	method  jwt.SigningMethod
+	keys    []SigningKey
+	overlap time.Duration
+}
+
+// NewKeyRing sorts the keys by activation. overlap must be at least the lifetime of the tokens.
+func NewKeyRing(method jwt.SigningMethod, keys []SigningKey, overlap time.Duration) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("the key ring has no keys")
+	}
+
+	sorted := append([]SigningKey(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ActiveFrom.Before(sorted[j].ActiveFrom) })
+
+	ids := make(map[string]bool, len(sorted))
+	for _, key := range sorted {
+		if key.ID == "" {
+			return nil, errors.New("every key needs a kid")
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("kid %q is used by more than one key", key.ID)
+		}
+		ids[key.ID] = true
+
+		if err := checkKeyType(method, key.Private); err != nil {
+			return nil, fmt.Errorf("key %q: %w", key.ID, err)
+		}
+	}
+
+	return &KeyRing{method: method, keys: sorted, overlap: overlap}, nil
+}
+
+// LoadKeyRing reads the keys listed in the keys.json manifest of dir, such as
+//
+//	{"keys": [{"kid": "2026-10", "file": "2026-10.pem", "active_from": "2026-10-01T00:00:00Z"}]}
+//
+// with PKCS#8, PKCS#1 or SEC 1 PEM private keys. Rotations are scheduled by listing the next key with a
+// future active_from.
+func LoadKeyRing(method string, dir string, overlap time.Duration) (*KeyRing, error) {
+	signingMethod := jwt.GetSigningMethod(method)
+	if signingMethod == nil {
+		return nil, fmt.Errorf("unknown signing method %q", method)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, KeyManifest))
+	if err != nil {
+		return nil, fmt.Errorf("error reading the key manifest: %w", err)
+	}
+
+	var manifest struct {
+		Keys []struct {
+			ID         string    `json:"kid"`
+			File       string    `json:"file"`
+			ActiveFrom time.Time `json:"active_from"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("error decoding the key manifest: %w", err)
+	}
+
+	keys := make([]SigningKey, 0, len(manifest.Keys))
+	for _, entry := range manifest.Keys {
+		private, err := readPrivateKey(filepath.Join(dir, entry.File))
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", entry.ID, err)
+		}
+		keys = append(keys, SigningKey{ID: entry.ID, ActiveFrom: entry.ActiveFrom, Private: private})
+	}
+
+	keyRing, err := NewKeyRing(signingMethod, keys, overlap)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := keyRing.SigningKey(time.Now()); err != nil {
+		return nil, err
+	}
+	return keyRing, nil
+}
+
+func (k *KeyRing) Method() jwt.SigningMethod {
+	return k.method
+}
+
+// SigningKey returns the most recently activated key
+func (k *KeyRing) SigningKey(now time.Time) (string, any, error) {
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		if !now.Before(k.keys[i].ActiveFrom) {
+			return k.keys[i].ID, k.keys[i].Private, nil
+		}
+	}
+	return "", nil, errors.New("no signing key is active yet")
+}
+
+func (k *KeyRing) VerificationKey(kid string, now time.Time) (any, error) {
+	for i, key := range k.keys {
+		if key.ID == kid && k.verifies(i, now) {
+			return key.Private.Public(), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown or retired kid %q", kid)
+}
+
+func (k *KeyRing) JWKS(now time.Time) entity.JSONWebKeySet {
+	set := entity.JSONWebKeySet{Keys: []entity.JSONWebKey{}}
+	for i, key := range k.keys {
+		if k.verifies(i, now) {
+			set.Keys = append(set.Keys, publicJWK(k.method, key))
+		}
+	}
+	return set
+}
+
+// verifies reports whether the key at i still verifies at now: it is the last key, or the key after it
+// activated less than the overlap ago
+func (k *KeyRing) verifies(i int, now time.Time) bool {
+	if i == len(k.keys)-1 {
+		return true
+	}
+	return now.Before(k.keys[i+1].ActiveFrom.Add(k.overlap))
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported private key format")
+}
+
+func checkKeyType(method jwt.SigningMethod, key crypto.Signer) error {
+	switch method {
+	case jwt.SigningMethodRS256:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("RS256 needs an RSA key, got %T", key)
+		}
+		if rsaKey.N.BitLen() < minRSAKeyBits {
+			return fmt.Errorf("RS256 needs an RSA key of at least %d bits", minRSAKeyBits)
+		}
+	case jwt.SigningMethodES256:
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return fmt.Errorf("ES256 needs a P-256 EC key, got %T", key)
+		}
+	default:
+		return fmt.Errorf("key rings do not support %s", method.Alg())
+	}
+	return nil
+}
+
+func publicJWK(method jwt.SigningMethod, key SigningKey) entity.JSONWebKey {
+	jwk := entity.JSONWebKey{KeyID: key.ID, Algorithm: method.Alg(), Use: "sig"}
+	encode := base64.RawURLEncoding.EncodeToString
+
+	switch public := key.Private.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = encode(public.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		// The uncompressed point is 0x04 followed by X and Y, each padded to the curve size
+		point, _ := public.ECDH()
+		coordinates := point.Bytes()[1:]
+		jwk.KeyType = "EC"
+		jwk.Curve = public.Curve.Params().Name
+		jwk.X = encode(coordinates[:len(coordinates)/2])
+		jwk.Y = encode(coordinates[len(coordinates)/2:])
+	}
+	return jwk
+}
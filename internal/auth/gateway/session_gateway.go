@@ -0,0 +1,25 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+)
+
+// SessionGateway stores the refresh tokens and the denylist of revoked access tokens
+type SessionGateway interface {
+	CreateRefreshToken(ctx context.Context, token entity.RefreshToken) error
+	// FindRefreshToken returns a NotFoundError for unknown tokens
+	FindRefreshToken(ctx context.Context, tokenHash string) (entity.RefreshToken, error)
+	// RotateRefreshToken marks the used token as used and stores the next token of its family in one
+	// transaction. It reports false, storing nothing, when the used token had already been used or its
+	// family was revoked, so only one of concurrent refreshes wins and a revoked session stays revoked.
+	RotateRefreshToken(ctx context.Context, usedID string, next entity.RefreshToken) (bool, error)
+	// RevokeFamily revokes the refresh tokens of the family and denies the access tokens issued with them
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeUser revokes every family of the user
+	RevokeUser(ctx context.Context, userID string) error
+	DenyAccessToken(ctx context.Context, id string, expiresAt time.Time) error
+	IsAccessTokenDenied(ctx context.Context, id string) (bool, error)
+}
@@ -5,6 +5,10 @@ import (
 )
 
 type TokenGateway interface {
-	GenerateToken(userID, userType string, additionalClaims map[string]any) (string, error)
+	// GenerateToken signs an access token. sessionID is the refresh token family it belongs to, empty for
+	// tokens issued without one.
+	GenerateToken(userID, userType string, role entity.Role, sessionID string, additionalClaims map[string]any) (entity.AccessToken, error)
 	ValidateToken(tokenString string) (*entity.CustomClaims, error)
+	// JWKS returns the public keys of the tokens, none when they are signed with a shared secret
+	JWKS() entity.JSONWebKeySet
 }
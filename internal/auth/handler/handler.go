@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/controller"
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge lets verifiers cache the keys. Keys are published well before they sign, so a cached set
+// never misses the signing key.
+const jwksMaxAge = "public, max-age=300"
+
+type Handler struct {
+	authController *controller.Controller
+}
+
+func New(authController *controller.Controller) *Handler {
+	return &Handler{
+		authController: authController,
+	}
+}
+
+// JWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys to verify the tokens issued by this service, by kid. Empty when the tokens are signed with HS256
+// @Tags         Auth
+// @Produce      json
+// @Success      200      {object}  entity.JSONWebKeySet
+// @Router       /.well-known/jwks.json [get]
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", jwksMaxAge)
+	c.JSON(http.StatusOK, h.authController.JWKS())
+}
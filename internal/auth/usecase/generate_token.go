@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/gateway"
 )
 
@@ -14,6 +15,7 @@ func NewGenerateTokenUseCase(tokenGateway gateway.TokenGateway) *GenerateTokenUs
 	}
 }
 
-func (uc *GenerateTokenUseCase) Execute(userID, userType string, additionalClaims map[string]any) (string, error) {
-	return uc.tokenGateway.GenerateToken(userID, userType, additionalClaims)
+// Execute issues a standalone access token, without a refresh token. It can still be revoked by its jti.
+func (uc *GenerateTokenUseCase) Execute(userID, userType string, role entity.Role, additionalClaims map[string]any) (entity.AccessToken, error) {
+	return uc.tokenGateway.GenerateToken(userID, userType, role, "", additionalClaims)
 }
@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/gateway"
+)
+
+type GetJWKSUseCase struct {
+	tokenGateway gateway.TokenGateway
+}
+
+func NewGetJWKSUseCase(tokenGateway gateway.TokenGateway) *GetJWKSUseCase {
+	return &GetJWKSUseCase{
+		tokenGateway: tokenGateway,
+	}
+}
+
+func (uc *GetJWKSUseCase) Execute() entity.JSONWebKeySet {
+	return uc.tokenGateway.JWKS()
+}
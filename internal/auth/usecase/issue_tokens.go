@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/gateway"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+)
+
+// refreshTokenBytes of randomness, the refresh token is a bearer secret
+const refreshTokenBytes = 32
+
+type IssueTokensUseCase struct {
+	tokenGateway   gateway.TokenGateway
+	sessionGateway gateway.SessionGateway
+	refreshExpiry  time.Duration
+}
+
+func NewIssueTokensUseCase(tokenGateway gateway.TokenGateway, sessionGateway gateway.SessionGateway, refreshExpiry time.Duration) *IssueTokensUseCase {
+	return &IssueTokensUseCase{
+		tokenGateway:   tokenGateway,
+		sessionGateway: sessionGateway,
+		refreshExpiry:  refreshExpiry,
+	}
+}
+
+// Execute issues an access token and a refresh token. familyID adds them to an existing session, an
+// empty one starts a new session on login. Refreshes go through RotateRefreshTokenUseCase instead.
+func (uc *IssueTokensUseCase) Execute(ctx context.Context, userID, userType string, role entity.Role, familyID string) (entity.TokenPair, error) {
+	if familyID == "" {
+		familyID = uuid.NewString()
+	}
+
+	tokens, record, err := newTokenPair(uc.tokenGateway, uc.refreshExpiry, userID, userType, role, familyID)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	if err := uc.sessionGateway.CreateRefreshToken(ctx, record); err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	return tokens, nil
+}
+
+// newTokenPair signs the access token and generates the refresh token of the family, returning the pair
+// for the client and the refresh token record to store
+func newTokenPair(tokenGateway gateway.TokenGateway, refreshExpiry time.Duration, userID, userType string, role entity.Role, familyID string) (entity.TokenPair, entity.RefreshToken, error) {
+	accessToken, err := tokenGateway.GenerateToken(userID, userType, role, familyID, nil)
+	if err != nil {
+		return entity.TokenPair{}, entity.RefreshToken{}, &apperror.InternalError{Msg: err.Error()}
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return entity.TokenPair{}, entity.RefreshToken{}, &apperror.InternalError{Msg: err.Error()}
+	}
+
+	now := time.Now()
+	record := entity.RefreshToken{
+		ID:              uuid.NewString(),
+		FamilyID:        familyID,
+		UserID:          userID,
+		UserType:        userType,
+		TokenHash:       hashRefreshToken(refreshToken),
+		AccessTokenID:   accessToken.ID,
+		AccessExpiresAt: accessToken.ExpiresAt,
+		ExpiresAt:       now.Add(refreshExpiry),
+		CreatedAt:       now,
+	}
+
+	return entity.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, record, nil
+}
+
+func newRefreshToken() (string, error) {
+	bytes := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashRefreshToken is enough without a salt, the tokens are random and long
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/gateway"
+)
+
+type RevokeSessionUseCase struct {
+	sessionGateway gateway.SessionGateway
+}
+
+func NewRevokeSessionUseCase(sessionGateway gateway.SessionGateway) *RevokeSessionUseCase {
+	return &RevokeSessionUseCase{
+		sessionGateway: sessionGateway,
+	}
+}
+
+// Execute revokes the session of the token: its refresh token family, with every access token issued
+// in it, and the token itself, which may have been issued without a session
+func (uc *RevokeSessionUseCase) Execute(ctx context.Context, claims *entity.CustomClaims) error {
+	if claims.SessionID != "" {
+		if err := uc.sessionGateway.RevokeFamily(ctx, claims.SessionID); err != nil {
+			return err
+		}
+	}
+
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return uc.sessionGateway.DenyAccessToken(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+// Family revokes a session by its refresh token family
+func (uc *RevokeSessionUseCase) Family(ctx context.Context, familyID string) error {
+	return uc.sessionGateway.RevokeFamily(ctx, familyID)
+}
+
+// User revokes every session of the user
+func (uc *RevokeSessionUseCase) User(ctx context.Context, userID string) error {
+	return uc.sessionGateway.RevokeUser(ctx, userID)
+}
@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/gateway"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+)
+
+type RotateRefreshTokenUseCase struct {
+	tokenGateway   gateway.TokenGateway
+	sessionGateway gateway.SessionGateway
+	refreshExpiry  time.Duration
+}
+
+func NewRotateRefreshTokenUseCase(tokenGateway gateway.TokenGateway, sessionGateway gateway.SessionGateway, refreshExpiry time.Duration) *RotateRefreshTokenUseCase {
+	return &RotateRefreshTokenUseCase{
+		tokenGateway:   tokenGateway,
+		sessionGateway: sessionGateway,
+		refreshExpiry:  refreshExpiry,
+	}
+}
+
+// Check returns the record of a refresh token that can be rotated, for the caller to decide the role of
+// the next tokens. A token used twice has leaked, so the whole family is revoked, locking out both the
+// client and whoever copied it.
+func (uc *RotateRefreshTokenUseCase) Check(ctx context.Context, refreshToken string) (entity.RefreshToken, error) {
+	token, err := uc.sessionGateway.FindRefreshToken(ctx, hashRefreshToken(refreshToken))
+	var notFoundErr *apperror.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return entity.RefreshToken{}, &apperror.UnauthorizedError{Msg: "Invalid refresh token"}
+	}
+	if err != nil {
+		return entity.RefreshToken{}, err
+	}
+
+	if token.RevokedAt != nil || token.Expired(time.Now()) {
+		return entity.RefreshToken{}, &apperror.UnauthorizedError{Msg: "Invalid refresh token"}
+	}
+
+	if token.UsedAt != nil {
+		return entity.RefreshToken{}, uc.revokeReused(ctx, token.FamilyID)
+	}
+
+	return token, nil
+}
+
+// Execute replaces the checked token with the next tokens of its family. The token is only consumed
+// together with storing its replacement, so a failed refresh can be retried with the same token. When
+// the token was used or its family revoked since it was checked nothing is issued, and the family is
+// revoked as on reuse.
+func (uc *RotateRefreshTokenUseCase) Execute(ctx context.Context, used entity.RefreshToken, role entity.Role) (entity.TokenPair, error) {
+	tokens, next, err := newTokenPair(uc.tokenGateway, uc.refreshExpiry, used.UserID, used.UserType, role, used.FamilyID)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	rotated, err := uc.sessionGateway.RotateRefreshToken(ctx, used.ID, next)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+	if !rotated {
+		return entity.TokenPair{}, uc.revokeReused(ctx, used.FamilyID)
+	}
+
+	return tokens, nil
+}
+
+func (uc *RotateRefreshTokenUseCase) revokeReused(ctx context.Context, familyID string) error {
+	if err := uc.sessionGateway.RevokeFamily(ctx, familyID); err != nil {
+		return err
+	}
+	return &apperror.UnauthorizedError{Msg: "Refresh token reuse detected, the session was revoked"}
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/external"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+)
+
+// fakeSessions keeps the refresh tokens by hash and the denylist in memory. beforeRotate runs inside
+// RotateRefreshToken, before the used token is checked, like a concurrent request would.
+type fakeSessions struct {
+	tokens       map[string]entity.RefreshToken
+	denied       map[string]time.Time
+	rotateErr    error
+	beforeRotate func()
+}
+
+func newFakeSessions() *fakeSessions {
+	return &fakeSessions{tokens: map[string]entity.RefreshToken{}, denied: map[string]time.Time{}}
+}
+
+func (f *fakeSessions) CreateRefreshToken(_ context.Context, token entity.RefreshToken) error {
+	f.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (f *fakeSessions) FindRefreshToken(_ context.Context, tokenHash string) (entity.RefreshToken, error) {
+	token, ok := f.tokens[tokenHash]
+	if !ok {
+		return entity.RefreshToken{}, &apperror.NotFoundError{Msg: "refresh token not found"}
+	}
+	return token, nil
+}
+
+func (f *fakeSessions) RotateRefreshToken(_ context.Context, usedID string, next entity.RefreshToken) (bool, error) {
+	if f.beforeRotate != nil {
+		f.beforeRotate()
+	}
+	if f.rotateErr != nil {
+		return false, f.rotateErr
+	}
+
+	for hash, token := range f.tokens {
+		if token.ID == usedID && token.UsedAt == nil && token.RevokedAt == nil {
+			now := time.Now()
+			token.UsedAt = &now
+			f.tokens[hash] = token
+			f.tokens[next.TokenHash] = next
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeSessions) RevokeFamily(_ context.Context, familyID string) error {
+	return f.revoke(func(token entity.RefreshToken) bool { return token.FamilyID == familyID })
+}
+
+func (f *fakeSessions) RevokeUser(_ context.Context, userID string) error {
+	return f.revoke(func(token entity.RefreshToken) bool { return token.UserID == userID })
+}
+
+func (f *fakeSessions) revoke(match func(entity.RefreshToken) bool) error {
+	now := time.Now()
+	for hash, token := range f.tokens {
+		if match(token) {
+			token.RevokedAt = &now
+			f.tokens[hash] = token
+			f.denied[token.AccessTokenID] = token.AccessExpiresAt
+		}
+	}
+	return nil
+}
+
+func (f *fakeSessions) DenyAccessToken(_ context.Context, id string, expiresAt time.Time) error {
+	f.denied[id] = expiresAt
+	return nil
+}
+
+func (f *fakeSessions) IsAccessTokenDenied(_ context.Context, id string) (bool, error) {
+	_, ok := f.denied[id]
+	return ok, nil
+}
+
+func TestRotateRefreshToken_ReuseRevokesTheFamily(t *testing.T) {
+	ctx := context.Background()
+	tokens := external.NewJWTService(external.NewHMACKey("secret"), time.Minute, "", "")
+	sessions := newFakeSessions()
+	issue := NewIssueTokensUseCase(tokens, sessions, time.Hour)
+	rotate := NewRotateRefreshTokenUseCase(tokens, sessions, time.Hour)
+	validate := NewValidateTokenUseCase(tokens, sessions)
+
+	login, err := issue.Execute(ctx, "1", "admin", entity.RoleKitchenStaff, "")
+	assert.NoError(t, err)
+
+	session, err := rotate.Check(ctx, login.RefreshToken)
+	assert.NoError(t, err)
+	refreshed, err := rotate.Execute(ctx, session, entity.RoleKitchenStaff)
+	assert.NoError(t, err)
+
+	claims, err := validate.Execute(ctx, refreshed.AccessToken.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, session.FamilyID, claims.SessionID)
+
+	// The first refresh token was stolen and is used again
+	_, err = rotate.Check(ctx, login.RefreshToken)
+	assert.EqualError(t, err, "Refresh token reuse detected, the session was revoked")
+
+	_, err = rotate.Check(ctx, refreshed.RefreshToken)
+	assert.IsType(t, &apperror.UnauthorizedError{}, err)
+	_, err = validate.Execute(ctx, refreshed.AccessToken.Token)
+	assert.EqualError(t, err, "Token has been revoked")
+}
+
+func TestRotateRefreshToken_RacingRevocation(t *testing.T) {
+	ctx := context.Background()
+	tokens := external.NewJWTService(external.NewHMACKey("secret"), time.Minute, "", "")
+	sessions := newFakeSessions()
+	rotate := NewRotateRefreshTokenUseCase(tokens, sessions, time.Hour)
+
+	login, err := NewIssueTokensUseCase(tokens, sessions, time.Hour).Execute(ctx, "1", "admin", entity.RoleManager, "")
+	assert.NoError(t, err)
+	session, err := rotate.Check(ctx, login.RefreshToken)
+	assert.NoError(t, err)
+
+	// The admin logs out on another device while the refresh is in flight
+	sessions.beforeRotate = func() { assert.NoError(t, sessions.RevokeFamily(ctx, session.FamilyID)) }
+	_, err = rotate.Execute(ctx, session, entity.RoleManager)
+
+	assert.IsType(t, &apperror.UnauthorizedError{}, err)
+	assert.Len(t, sessions.tokens, 1, "no token is added to the revoked family")
+	for _, token := range sessions.tokens {
+		assert.NotNil(t, token.RevokedAt)
+	}
+}
+
+func TestRotateRefreshToken_FailedRotationKeepsTheToken(t *testing.T) {
+	ctx := context.Background()
+	tokens := external.NewJWTService(external.NewHMACKey("secret"), time.Minute, "", "")
+	sessions := newFakeSessions()
+	rotate := NewRotateRefreshTokenUseCase(tokens, sessions, time.Hour)
+
+	login, err := NewIssueTokensUseCase(tokens, sessions, time.Hour).Execute(ctx, "1", "admin", entity.RoleCashier, "")
+	assert.NoError(t, err)
+	session, err := rotate.Check(ctx, login.RefreshToken)
+	assert.NoError(t, err)
+
+	sessions.rotateErr = &apperror.TimeoutError{Msg: "database timeout"}
+	_, err = rotate.Execute(ctx, session, entity.RoleCashier)
+	assert.IsType(t, &apperror.TimeoutError{}, err)
+
+	// The token was not consumed, so the client retries with it
+	sessions.rotateErr = nil
+	session, err = rotate.Check(ctx, login.RefreshToken)
+	assert.NoError(t, err)
+	_, err = rotate.Execute(ctx, session, entity.RoleCashier)
+	assert.NoError(t, err)
+}
+
+func TestRevokeSession_Logout(t *testing.T) {
+	ctx := context.Background()
+	tokens := external.NewJWTService(external.NewHMACKey("secret"), time.Minute, "", "")
+	sessions := newFakeSessions()
+	validate := NewValidateTokenUseCase(tokens, sessions)
+
+	login, err := NewIssueTokensUseCase(tokens, sessions, time.Hour).Execute(ctx, "1", "admin", entity.RoleCashier, "")
+	assert.NoError(t, err)
+	claims, err := validate.Execute(ctx, login.AccessToken.Token)
+	assert.NoError(t, err)
+
+	assert.NoError(t, NewRevokeSessionUseCase(sessions).Execute(ctx, claims))
+
+	_, err = validate.Execute(ctx, login.AccessToken.Token)
+	assert.IsType(t, &apperror.UnauthorizedError{}, err)
+	_, err = NewRotateRefreshTokenUseCase(tokens, sessions, time.Hour).Check(ctx, login.RefreshToken)
+	assert.EqualError(t, err, "Invalid refresh token")
+}
@@ -1,20 +1,45 @@
 package usecase
 
 import (
+	"context"
+
 	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/gateway"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
 )
 
 type ValidateTokenUseCase struct {
-	tokenGateway gateway.TokenGateway
+	tokenGateway   gateway.TokenGateway
+	sessionGateway gateway.SessionGateway
 }
 
-func NewValidateTokenUseCase(tokenGateway gateway.TokenGateway) *ValidateTokenUseCase {
+func NewValidateTokenUseCase(tokenGateway gateway.TokenGateway, sessionGateway gateway.SessionGateway) *ValidateTokenUseCase {
 	return &ValidateTokenUseCase{
-		tokenGateway: tokenGateway,
+		tokenGateway:   tokenGateway,
+		sessionGateway: sessionGateway,
 	}
 }
 
-func (uc *ValidateTokenUseCase) Execute(tokenString string) (*entity.CustomClaims, error) {
-	return uc.tokenGateway.ValidateToken(tokenString)
+// Execute returns an UnauthorizedError for invalid and revoked tokens. Other errors mean the denylist
+// could not be checked.
+func (uc *ValidateTokenUseCase) Execute(ctx context.Context, tokenString string) (*entity.CustomClaims, error) {
+	claims, err := uc.tokenGateway.ValidateToken(tokenString)
+	if err != nil {
+		return nil, &apperror.UnauthorizedError{Msg: err.Error()}
+	}
+
+	// Tokens without a jti, such as those of the central auth service, are not revoked here
+	if claims.ID == "" {
+		return claims, nil
+	}
+
+	denied, err := uc.sessionGateway.IsAccessTokenDenied(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if denied {
+		return nil, &apperror.UnauthorizedError{Msg: "Token has been revoked"}
+	}
+
+	return claims, nil
 }
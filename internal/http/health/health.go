@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultCheckTimeout bounds every readiness check so a hanging dependency cannot hang the probe
+const DefaultCheckTimeout = 2 * time.Second
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check is a dependency probed by the readiness endpoint. A failing non critical check is reported
+// without making the service unready.
+type Check struct {
+	Name     string
+	Critical bool
+	Probe    func(ctx context.Context) error
+}
+
+// CheckResultDTO is the outcome of a single check
+type CheckResultDTO struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ResponseDTO is the body of the health endpoints
+type ResponseDTO struct {
+	Status string                    `json:"status"`
+	Checks map[string]CheckResultDTO `json:"checks,omitempty"`
+}
+
+// Handler serves the liveness and readiness probes
+type Handler struct {
+	checks       []Check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func New(timeout time.Duration, checks ...Check) *Handler {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	return &Handler{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// SetShuttingDown makes the service unready so the load balancer stops sending new requests
+// while the in-flight ones drain
+func (h *Handler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Live godoc
+// @Summary      Liveness probe
+// @Description  Answers while the process is running, without checking its dependencies
+// @Tags         Health
+// @Produce      json
+// @Success      200 {object}  health.ResponseDTO
+// @Router       /health/live [get]
+func (h *Handler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, ResponseDTO{Status: StatusUp})
+}
+
+// Ready godoc
+// @Summary      Readiness probe
+// @Description  Checks the database and, when configured, the downstream services. Answers 503 when a
+// @Description  critical check fails or the service is shutting down
+// @Tags         Health
+// @Produce      json
+// @Success      200 {object}  health.ResponseDTO
+// @Failure      503 {object}  health.ResponseDTO
+// @Router       /health/ready [get]
+func (h *Handler) Ready(c *gin.Context) {
+	if h.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, ResponseDTO{Status: StatusDown})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	response := ResponseDTO{Status: StatusUp, Checks: h.run(ctx)}
+	for _, result := range response.Checks {
+		if result.Critical && result.Status == StatusDown {
+			response.Status = StatusDown
+		}
+	}
+
+	status := http.StatusOK
+	if response.Status == StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
+
+// run probes every check concurrently
+func (h *Handler) run(ctx context.Context) map[string]CheckResultDTO {
+	results := make(map[string]CheckResultDTO, len(h.checks))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := CheckResultDTO{Status: StatusUp, Critical: check.Critical}
+			if err := check.Probe(ctx); err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Ready(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+
+	tests := []struct {
+		name               string
+		checks             []Check
+		shuttingDown       bool
+		expectedStatusCode int
+		expectedStatus     string
+	}{
+		{
+			name:               "all checks up",
+			checks:             []Check{{Name: "database", Critical: true, Probe: up}},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     StatusUp,
+		},
+		{
+			name:               "critical check down",
+			checks:             []Check{{Name: "database", Critical: true, Probe: down}},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     StatusDown,
+		},
+		{
+			name: "non critical check down is only reported",
+			checks: []Check{
+				{Name: "database", Critical: true, Probe: up},
+				{Name: "payment_service", Probe: down},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     StatusUp,
+		},
+		{
+			name:               "shutting down",
+			checks:             []Check{{Name: "database", Critical: true, Probe: up}},
+			shuttingDown:       true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     StatusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			handler := New(0, tt.checks...)
+			if tt.shuttingDown {
+				handler.SetShuttingDown()
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+			handler.Ready(c)
+
+			var response ResponseDTO
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			assert.Equal(t, tt.expectedStatus, response.Status)
+		})
+	}
+}
@@ -1,23 +1,25 @@
 package middleware
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/helper"
 )
 
 func AdminOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userType, exists := c.Get("user_type")
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "User type not found"})
+			helper.HandleError(c, &apperror.ForbiddenError{Msg: "user type not found"})
 			return
 		}
 
 		userTypeStr, ok := userType.(string)
 		if !ok || strings.ToLower(userTypeStr) != "admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			helper.HandleError(c, &apperror.ForbiddenError{Msg: "admin access required"})
 			return
 		}
 
@@ -1,11 +1,14 @@
 package middleware
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
 )
 
 func TestAdminOnly(t *testing.T) {
@@ -14,23 +17,27 @@ func TestAdminOnly(t *testing.T) {
 		userType           any
 		setUserType        bool
 		expectedStatusCode int
+		expectedCode       string
 	}{
 		{
 			name:               "missing user_type in context",
 			setUserType:        false,
 			expectedStatusCode: http.StatusForbidden,
+			expectedCode:       apperror.CodeForbidden,
 		},
 		{
 			name:               "non-string user_type",
 			setUserType:        true,
 			userType:           123,
 			expectedStatusCode: http.StatusForbidden,
+			expectedCode:       apperror.CodeForbidden,
 		},
 		{
 			name:               "non-admin user_type",
 			setUserType:        true,
 			userType:           "customer",
 			expectedStatusCode: http.StatusForbidden,
+			expectedCode:       apperror.CodeForbidden,
 		},
 		{
 			name:               "admin user_type",
@@ -63,6 +70,13 @@ func TestAdminOnly(t *testing.T) {
 			if resp.Code != tt.expectedStatusCode {
 				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, resp.Code)
 			}
+
+			if tt.expectedCode != "" {
+				var body apperror.ErrorDTO
+				if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil || body.Code != tt.expectedCode {
+					t.Errorf("expected error code %q, got %q", tt.expectedCode, body.Code)
+				}
+			}
 		})
 	}
 }
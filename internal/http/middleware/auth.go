@@ -1,37 +1,68 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
+	"errors"
 	"strings"
 
 	authcontroller "github.com/fiap-161/tc-golunch-operation-service/internal/auth/controller"
+	authentity "github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/helper"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(authController *authcontroller.Controller) gin.HandlerFunc {
+// AdminAccounts tells whether the admin account a token was issued to can still be used
+type AdminAccounts interface {
+	AccountActive(ctx context.Context, id string) (bool, error)
+}
+
+// AuthMiddleware rejects invalid, expired and revoked tokens. When accounts is set, which is only the
+// case in the local auth mode, admin tokens are also rejected once their account is disabled or deleted.
+// When the denylist or the account cannot be checked the request fails as unavailable rather than
+// letting a revoked token through. Admin tokens without a role get the permissions of defaultAdminRole.
+func AuthMiddleware(authController *authcontroller.Controller, accounts AdminAccounts, defaultAdminRole authentity.Role) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing"})
+			helper.HandleError(c, &apperror.UnauthorizedError{Msg: "authorization header missing"})
 			return
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid Authorization header format"})
+			helper.HandleError(c, &apperror.UnauthorizedError{Msg: "invalid authorization header format"})
 			return
 		}
 
 		tokenString := parts[1]
 
-		claims, err := authController.ValidateToken(tokenString)
+		claims, err := authController.ValidateToken(c.Request.Context(), tokenString)
+		var unauthorizedErr *apperror.UnauthorizedError
+		if errors.As(err, &unauthorizedErr) {
+			helper.HandleError(c, err)
+			return
+		}
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			helper.HandleError(c, &apperror.UnavailableError{Msg: "unable to validate token: " + err.Error()})
 			return
 		}
 
+		if accounts != nil && claims.UserType == "admin" {
+			active, err := accounts.AccountActive(c.Request.Context(), claims.UserID)
+			if err != nil {
+				helper.HandleError(c, &apperror.UnavailableError{Msg: "unable to validate token: " + err.Error()})
+				return
+			}
+			if !active {
+				helper.HandleError(c, &apperror.UnauthorizedError{Msg: "admin account is disabled"})
+				return
+			}
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("user_type", claims.UserType)
+		c.Set("permissions", claims.GrantedPermissions(defaultAdminRole))
 		c.Set("claims", claims)
 
 		c.Next()
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/gateway"
 	"github.com/gin-gonic/gin"
 )
@@ -35,6 +36,7 @@ func ServerlessAuthMiddleware(authGateway *gateway.ServerlessAuthGateway) gin.Ha
 		// Set context values exactly like monolith
 		c.Set("user_id", claims.UserID)
 		c.Set("user_type", claims.UserType)
+		c.Set("permissions", entity.GrantedPermissions(claims.Role, claims.Permissions))
 		c.Set("claims", claims)
 
 		c.Next()
@@ -43,6 +45,7 @@ func ServerlessAuthMiddleware(authGateway *gateway.ServerlessAuthGateway) gin.Ha
 
 // ServerlessAdminOnly middleware to restrict access to admin users only
 // Following the same pattern as tc-golunch-api monolith
+// Routes needing more than a staff user combine it with RequirePermission
 func ServerlessAdminOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userType, exists := c.Get("user_type")
@@ -1,57 +1,125 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	authcontroller "github.com/fiap-161/tc-golunch-operation-service/internal/auth/controller"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/external"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/gateway"
 
 	"github.com/gin-gonic/gin"
 )
 
+// fakeDenylist is a SessionGateway that only answers denylist lookups
+type fakeDenylist struct {
+	gateway.SessionGateway
+	denied map[string]bool
+	err    error
+}
+
+func (f fakeDenylist) IsAccessTokenDenied(_ context.Context, id string) (bool, error) {
+	return f.denied[id], f.err
+}
+
+// fakeAdminAccounts answers whether each admin account is active
+type fakeAdminAccounts struct {
+	active map[string]bool
+	err    error
+}
+
+func (f fakeAdminAccounts) AccountActive(_ context.Context, id string) (bool, error) {
+	return f.active[id], f.err
+}
+
 func TestAuthMiddleware(t *testing.T) {
-	jwtGateway := external.NewJWTService("secret", time.Minute*5)
-	controller := authcontroller.New(jwtGateway)
-	validToken, err := controller.GenerateToken("user123", "admin", nil)
+	jwtGateway := external.NewJWTService(external.NewHMACKey("secret"), time.Minute*5, "", "")
+	validToken, err := jwtGateway.GenerateToken("user123", "admin", entity.RoleAdmin, "", nil)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	revokedToken, err := jwtGateway.GenerateToken("user123", "admin", entity.RoleAdmin, "", nil)
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
+	denylist := fakeDenylist{denied: map[string]bool{revokedToken.ID: true}}
 
 	tests := []struct {
 		name               string
 		authHeader         string
+		denylist           fakeDenylist
+		accounts           AdminAccounts
 		expectedStatusCode int
 	}{
 		{
 			name:               "missing Authorization header",
 			authHeader:         "",
+			denylist:           denylist,
 			expectedStatusCode: http.StatusUnauthorized,
 		},
 		{
 			name:               "invalid format in Authorization header",
 			authHeader:         "InvalidHeader",
+			denylist:           denylist,
 			expectedStatusCode: http.StatusUnauthorized,
 		},
 		{
 			name:               "invalid token value",
 			authHeader:         "Bearer invalid.token.value",
+			denylist:           denylist,
 			expectedStatusCode: http.StatusUnauthorized,
 		},
 		{
 			name:               "valid token",
-			authHeader:         "Bearer " + validToken,
+			authHeader:         "Bearer " + validToken.Token,
+			denylist:           denylist,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "revoked token",
+			authHeader:         "Bearer " + revokedToken.Token,
+			denylist:           denylist,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "denylist unavailable",
+			authHeader:         "Bearer " + validToken.Token,
+			denylist:           fakeDenylist{err: errors.New("connection refused")},
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:               "active admin account",
+			authHeader:         "Bearer " + validToken.Token,
+			denylist:           denylist,
+			accounts:           fakeAdminAccounts{active: map[string]bool{"user123": true}},
 			expectedStatusCode: http.StatusOK,
 		},
+		{
+			name:               "disabled admin account",
+			authHeader:         "Bearer " + validToken.Token,
+			denylist:           denylist,
+			accounts:           fakeAdminAccounts{active: map[string]bool{"user123": false}},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "admin accounts unavailable",
+			authHeader:         "Bearer " + validToken.Token,
+			denylist:           denylist,
+			accounts:           fakeAdminAccounts{err: errors.New("connection refused")},
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			gin.SetMode(gin.TestMode)
 			router := gin.New()
-			router.Use(AuthMiddleware(controller))
+			router.Use(AuthMiddleware(authcontroller.New(jwtGateway, tt.denylist, time.Hour), tt.accounts, ""))
 			router.GET("/protected", func(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{"message": "OK"})
 			})
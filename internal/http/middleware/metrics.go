@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// unmatchedRoute labels requests that matched no route, so unknown paths do not create new series
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of the HTTP requests served, by route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests currently being served.",
+	})
+)
+
+// Metrics records the latency of every request labelled with its route template, so all the
+// requests to /admin/orders/:id share one series. Streaming routes, whose connections stay open for
+// as long as the client watches, are left out of the latency and of the requests in flight the
+// autoscaler follows.
+func Metrics(streamingRoutes ...string) gin.HandlerFunc {
+	streaming := make(map[string]bool, len(streamingRoutes))
+	for _, route := range streamingRoutes {
+		streaming[route] = true
+	}
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		if streaming[route] {
+			c.Next()
+			return
+		}
+
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+
+		httpRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_StreamingRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Metrics("/metrics-test/stream"))
+
+	var inFlight float64
+	handler := func(c *gin.Context) {
+		inFlight = testutil.ToFloat64(httpRequestsInFlight)
+		c.Status(http.StatusOK)
+	}
+	router.GET("/metrics-test/orders", handler)
+	router.GET("/metrics-test/stream", handler)
+
+	tests := []struct {
+		path         string
+		wantInFlight float64
+		wantSeries   int
+	}{
+		{path: "/metrics-test/orders", wantInFlight: 1, wantSeries: 1},
+		{path: "/metrics-test/stream", wantInFlight: 0, wantSeries: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			series := testutil.CollectAndCount(httpRequestDuration)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantInFlight, inFlight)
+			assert.Equal(t, series+tt.wantSeries, testutil.CollectAndCount(httpRequestDuration))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		keepsID bool
+	}{
+		{name: "valid id is kept", header: "req-123", keepsID: true},
+		{name: "missing id is generated", header: ""},
+		{name: "invalid id is replaced", header: "bad id\nwith newline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(RequestID())
+
+			var fromContext string
+			r.GET("/", func(c *gin.Context) {
+				fromContext = requestid.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(requestid.Header)
+			assert.NotEmpty(t, id)
+			assert.Equal(t, id, fromContext)
+			if tt.keepsID {
+				assert.Equal(t, tt.header, id)
+			} else {
+				assert.NotEqual(t, tt.header, id)
+			}
+		})
+	}
+}
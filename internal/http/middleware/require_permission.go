@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"slices"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission allows the request only when the token grants every permission. It runs after
+// AuthMiddleware or ServerlessAuthMiddleware, which set the granted permissions.
+func RequirePermission(permissions ...entity.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("permissions")
+		if !exists {
+			helper.HandleError(c, &apperror.ForbiddenError{Msg: "permissions not found"})
+			return
+		}
+
+		granted, ok := value.([]entity.Permission)
+		if !ok {
+			helper.HandleError(c, &apperror.ForbiddenError{Msg: "permissions not found"})
+			return
+		}
+
+		for _, permission := range permissions {
+			if !slices.Contains(granted, permission) {
+				helper.HandleError(c, &apperror.ForbiddenError{Msg: "permission required: " + string(permission)})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
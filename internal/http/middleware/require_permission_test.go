@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	authcontroller "github.com/fiap-161/tc-golunch-operation-service/internal/auth/controller"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/external"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name               string
+		claims             *entity.CustomClaims
+		defaultAdminRole   entity.Role
+		expectedStatusCode int
+	}{
+		{
+			name:               "missing permissions in context",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "kitchen staff cannot cancel",
+			claims:             &entity.CustomClaims{UserType: "admin", Role: entity.RoleKitchenStaff, Permissions: entity.RoleKitchenStaff.Permissions()},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "cashier can cancel",
+			claims:             &entity.CustomClaims{UserType: "admin", Role: entity.RoleCashier, Permissions: entity.RoleCashier.Permissions()},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "permissions of the role when the token carries none",
+			claims:             &entity.CustomClaims{UserType: "admin", Role: entity.RoleManager},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "admin without role nor default role",
+			claims:             &entity.CustomClaims{UserType: "admin"},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "admin without role gets the default role",
+			claims:             &entity.CustomClaims{UserType: "admin"},
+			defaultAdminRole:   entity.RoleCashier,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "admin without role is limited to the default role",
+			claims:             &entity.CustomClaims{UserType: "admin"},
+			defaultAdminRole:   entity.RoleKitchenStaff,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "permissions without role nor default role",
+			claims:             &entity.CustomClaims{UserType: "admin", Permissions: entity.RoleAdmin.Permissions()},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "permissions without role are limited to the default role",
+			claims:             &entity.CustomClaims{UserType: "admin", Permissions: entity.RoleAdmin.Permissions()},
+			defaultAdminRole:   entity.RoleKitchenStaff,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "permissions beyond the role",
+			claims:             &entity.CustomClaims{UserType: "admin", Role: entity.RoleKitchenStaff, Permissions: entity.RoleCashier.Permissions()},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "customer",
+			claims:             &entity.CustomClaims{UserType: "customer"},
+			defaultAdminRole:   entity.RoleAdmin,
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+
+			router.Use(func(c *gin.Context) {
+				if tt.claims != nil {
+					c.Set("permissions", tt.claims.GrantedPermissions(tt.defaultAdminRole))
+				}
+			}, RequirePermission(entity.PermissionOrdersRead, entity.PermissionOrdersCancel))
+
+			router.POST("/admin/orders/:id/cancel", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "Access granted"})
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/orders/1/cancel", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.expectedStatusCode {
+				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, resp.Code)
+			}
+
+			if tt.expectedStatusCode == http.StatusForbidden {
+				var body apperror.ErrorDTO
+				if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil || body.Code != apperror.CodeForbidden {
+					t.Errorf("expected error code %q, got %q", apperror.CodeForbidden, body.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestRequirePermission_TokenWithoutRole(t *testing.T) {
+	jwtGateway := external.NewJWTService(external.NewHMACKey("secret"), time.Minute*5, "", "")
+	token, err := jwtGateway.GenerateToken("user123", "admin", "", "", nil)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		defaultAdminRole   entity.Role
+		expectedStatusCode int
+	}{
+		{name: "no default role", expectedStatusCode: http.StatusForbidden},
+		{name: "default role", defaultAdminRole: entity.RoleManager, expectedStatusCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(AuthMiddleware(authcontroller.New(jwtGateway, fakeDenylist{}, time.Hour), nil, tt.defaultAdminRole))
+			router.GET("/admin/orders", RequirePermission(entity.PermissionOrdersRead), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "Access granted"})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/orders", nil)
+			req.Header.Set("Authorization", "Bearer "+token.Token)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.expectedStatusCode {
+				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, resp.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/tracing"
+)
+
+// untracedRoutes are polled by the platform every few seconds and would only add noise to the traces
+var untracedRoutes = map[string]bool{
+	"/health/live":  true,
+	"/health/ready": true,
+	"/metrics":      true,
+}
+
+// Tracing starts a server span for every request, continuing the trace of the caller when the
+// request carries a W3C traceparent header. The span is named after the route template.
+func Tracing() gin.HandlerFunc {
+	tracer := otel.Tracer(tracing.InstrumentationName)
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if untracedRoutes[route] {
+			c.Next()
+			return
+		}
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracer.Start(ctx, fmt.Sprintf("%s %s", c.Request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.ClientAddress(c.ClientIP()),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+		// Client errors are the caller's problem, only server errors mark the span as failed
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/helper"
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the timestamp and the request body, as
+	// computed by SignBody, optionally prefixed with "sha256="
+	SignatureHeader = "X-Signature"
+	// SignatureTimestampHeader carries the unix time in seconds at which the request was signed
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+
+	// SignatureTolerance is how far the signature timestamp may be from the current time, so a captured
+	// request cannot be replayed later
+	SignatureTolerance = 5 * time.Minute
+	// MaxWebhookBodyBytes bounds the body read to check the signature, notifications are much smaller
+	MaxWebhookBodyBytes = 64 << 10
+)
+
+// PaymentWebhookAuth accepts requests from the payment service authenticated either with its service
+// API key, as in ServiceAuthMiddleware, or with an HMAC signature of a recent timestamp and the raw
+// body. An empty secret disables the signature method.
+func PaymentWebhookAuth(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxWebhookBodyBytes)
+
+		serviceName := c.GetHeader("X-Service-Name")
+		serviceKey := c.GetHeader("X-Service-Key")
+		if serviceName == "payment-service" && validateServiceAPIKey(serviceName, serviceKey) {
+			c.Set("authenticated_service", serviceName)
+			c.Next()
+			return
+		}
+
+		signature := c.GetHeader(SignatureHeader)
+		timestamp := c.GetHeader(SignatureTimestampHeader)
+		if secret == "" || signature == "" || timestamp == "" {
+			helper.HandleError(c, &apperror.UnauthorizedError{Msg: "missing webhook credentials"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, apperror.ErrorDTO{
+					Code:         apperror.CodePayloadTooLarge,
+					Message:      "request body too large",
+					MessageError: err.Error(),
+				})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, apperror.ErrorDTO{
+				Code:         apperror.CodeInvalidRequest,
+				Message:      "invalid request body",
+				MessageError: err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !recentTimestamp(timestamp, time.Now()) {
+			helper.HandleError(c, &apperror.UnauthorizedError{Msg: "webhook signature timestamp is invalid or outside the tolerance"})
+			return
+		}
+		if !validSignature(secret, timestamp, body, signature) {
+			helper.HandleError(c, &apperror.UnauthorizedError{Msg: "invalid webhook signature"})
+			return
+		}
+
+		c.Set("authenticated_service", "payment-service")
+		c.Next()
+	}
+}
+
+// SignBody signs the timestamp, in unix seconds, together with the body as "<timestamp>.<body>"
+func SignBody(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func recentTimestamp(timestamp string, now time.Time) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	return age <= SignatureTolerance && age >= -SignatureTolerance
+}
+
+func validSignature(secret string, timestamp string, body []byte, signature string) bool {
+	received, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+
+	expected, _ := hex.DecodeString(SignBody(secret, timestamp, body))
+	return hmac.Equal(received, expected)
+}
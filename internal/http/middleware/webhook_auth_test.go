@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPaymentWebhookAuth(t *testing.T) {
+	const secret = "webhook-secret"
+	const body = `{"order_id":"123","status":"approved"}`
+	t.Setenv("PAYMENT_SERVICE_API_KEY", "payment-key")
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-SignatureTolerance-time.Minute).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(SignatureTolerance+time.Minute).Unix(), 10)
+	oversized := `{"padding":"` + strings.Repeat("x", MaxWebhookBodyBytes) + `"}`
+
+	signed := func(secret, timestamp, body string) map[string]string {
+		return map[string]string{
+			SignatureTimestampHeader: timestamp,
+			SignatureHeader:          SignBody(secret, timestamp, []byte(body)),
+		}
+	}
+
+	tests := []struct {
+		name               string
+		secret             string
+		body               string
+		headers            map[string]string
+		expectedStatusCode int
+	}{
+		{
+			name:               "missing credentials",
+			secret:             secret,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "valid signature",
+			secret:             secret,
+			headers:            signed(secret, now, body),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "valid prefixed signature",
+			secret: secret,
+			headers: map[string]string{
+				SignatureTimestampHeader: now,
+				SignatureHeader:          "sha256=" + SignBody(secret, now, []byte(body)),
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "signature from another secret",
+			secret:             secret,
+			headers:            signed("other", now, body),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "signature without timestamp",
+			secret:             secret,
+			headers:            map[string]string{SignatureHeader: SignBody(secret, now, []byte(body))},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "replayed after the tolerance",
+			secret:             secret,
+			headers:            signed(secret, stale, body),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "timestamp too far ahead",
+			secret:             secret,
+			headers:            signed(secret, future, body),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "replayed with a fresh timestamp",
+			secret: secret,
+			headers: map[string]string{
+				SignatureTimestampHeader: now,
+				SignatureHeader:          SignBody(secret, stale, []byte(body)),
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "oversized body",
+			secret:             secret,
+			body:               oversized,
+			headers:            signed(secret, now, oversized),
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "signature disabled without secret",
+			secret:             "",
+			headers:            signed("", now, body),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "payment service api key",
+			secret:             "",
+			headers:            map[string]string{"X-Service-Name": "payment-service", "X-Service-Key": "payment-key"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "api key of another service",
+			secret:             secret,
+			headers:            map[string]string{"X-Service-Name": "core-service", "X-Service-Key": "payment-key"},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.body == "" {
+				tt.body = body
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(PaymentWebhookAuth(tt.secret))
+			router.POST("/webhooks/payment", func(c *gin.Context) {
+				received, _ := io.ReadAll(c.Request.Body)
+				if string(received) != tt.body {
+					t.Errorf("expected body to be preserved, got %q", received)
+				}
+				c.JSON(http.StatusOK, gin.H{"message": "OK"})
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/payment", strings.NewReader(tt.body))
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.expectedStatusCode {
+				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, resp.Code)
+			}
+		})
+	}
+}
@@ -18,19 +18,26 @@ func Build(orderUseCase *usecases.UseCases) *Controller {
 	}
 }
 
-func (c *Controller) Create(ctx context.Context, orderDTO dto.CreateOrderDTO) (string, error) {
-	return c.orderUseCase.CreateCompleteOrder(ctx, orderDTO)
+func (c *Controller) Create(ctx context.Context, orderDTO dto.CreateOrderDTO) (dto.CreateOrderResponseDTO, error) {
+	presenter := presenter.Build()
+
+	order, err := c.orderUseCase.CreateCompleteOrder(ctx, orderDTO)
+	if err != nil {
+		return dto.CreateOrderResponseDTO{}, err
+	}
+
+	return presenter.FromEntityToCreateResponse(order), nil
 }
 
-func (c *Controller) GetAll(ctx context.Context, id string) ([]dto.OrderDAO, error) {
+func (c *Controller) GetAll(ctx context.Context, filter dto.OrderFilterDTO) (dto.OrderResponseListDTO, error) {
 	presenter := presenter.Build()
 
-	orders, err := c.orderUseCase.GetAllOrById(ctx, id)
+	orders, total, err := c.orderUseCase.GetAllOrById(ctx, filter)
 	if err != nil {
-		return nil, err
+		return dto.OrderResponseListDTO{}, err
 	}
 
-	return presenter.FromEntityListToDAOList(orders), nil
+	return presenter.FromEntityListToPage(orders, filter, total), nil
 }
 
 func (c *Controller) GetPanel(ctx context.Context) ([]dto.OrderDAO, error) {
@@ -55,14 +62,78 @@ func (c *Controller) FindByID(ctx context.Context, id string) (dto.OrderDAO, err
 	return presenter.FromEntityToDAO(order), nil
 }
 
-func (c *Controller) Update(ctx context.Context, orderDTO dto.OrderDAO) (dto.OrderDAO, error) {
+func (c *Controller) Update(ctx context.Context, orderDTO dto.OrderDAO, changedBy string) (dto.OrderDAO, error) {
 	presenter := presenter.Build()
 
 	order := dto.FromOrderDAO(orderDTO)
-	updated, err := c.orderUseCase.Update(ctx, order)
+	updated, err := c.orderUseCase.Update(ctx, order, changedBy)
 	if err != nil {
 		return dto.OrderDAO{}, err
 	}
 
 	return presenter.FromEntityToDAO(updated), nil
 }
+
+func (c *Controller) GetPayment(ctx context.Context, orderID string) (dto.PaymentDTO, error) {
+	order, err := c.FindByID(ctx, orderID)
+	if err != nil {
+		return dto.PaymentDTO{}, err
+	}
+
+	return dto.ToPaymentDTO(order), nil
+}
+
+func (c *Controller) Cancel(ctx context.Context, orderID string, cancelDTO dto.CancelOrderDTO, changedBy string) (dto.OrderDAO, error) {
+	presenter := presenter.Build()
+
+	cancelled, err := c.orderUseCase.Cancel(ctx, orderID, cancelDTO.Reason, changedBy)
+	if err != nil {
+		return dto.OrderDAO{}, err
+	}
+
+	return presenter.FromEntityToDAO(cancelled), nil
+}
+
+func (c *Controller) ProcessPaymentNotification(ctx context.Context, notification dto.PaymentNotificationDTO) (dto.PaymentNotificationResultDTO, error) {
+	approved := notification.Status == dto.PaymentNotificationApproved
+
+	order, applied, err := c.orderUseCase.ApplyPaymentResult(ctx, notification.OrderID, approved)
+	if err != nil {
+		return dto.PaymentNotificationResultDTO{}, err
+	}
+
+	return dto.PaymentNotificationResultDTO{
+		OrderID: order.ID,
+		Status:  order.Status.String(),
+		Applied: applied,
+	}, nil
+}
+
+func (c *Controller) GetStatusHistory(ctx context.Context, orderID string) (dto.OrderStatusHistoryResponseDTO, error) {
+	presenter := presenter.Build()
+
+	history, err := c.orderUseCase.GetStatusHistory(ctx, orderID)
+	if err != nil {
+		return dto.OrderStatusHistoryResponseDTO{}, err
+	}
+
+	return presenter.FromHistoryListToResponse(orderID, history), nil
+}
+
+func (c *Controller) GetStuckOutboxEntries(ctx context.Context) (dto.OutboxEntryListDTO, error) {
+	entries, err := c.orderUseCase.GetStuckOutboxEntries(ctx)
+	if err != nil {
+		return dto.OutboxEntryListDTO{}, err
+	}
+
+	return dto.OutboxEntryListDTO{Entries: dto.ToOutboxEntryDAOList(entries)}, nil
+}
+
+func (c *Controller) RetryOutboxEntry(ctx context.Context, id string) (dto.OutboxEntryDAO, error) {
+	entry, err := c.orderUseCase.RetryOutboxEntry(ctx, id)
+	if err != nil {
+		return dto.OutboxEntryDAO{}, err
+	}
+
+	return dto.ToOutboxEntryDAO(entry), nil
+}
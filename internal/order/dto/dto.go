@@ -2,11 +2,15 @@ package dto
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	orderentity "github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/money"
 )
 
 type CreateOrderDTO struct {
@@ -18,9 +22,35 @@ type UpdateOrderDTO struct {
 	Status string `json:"status" binding:"required"`
 }
 
+type CancelOrderDTO struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+const (
+	PaymentNotificationApproved = "approved"
+	PaymentNotificationRejected = "rejected"
+)
+
+type PaymentNotificationDTO struct {
+	OrderID   string `json:"order_id" binding:"required"`
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status" binding:"required,oneof=approved rejected"`
+}
+
+type PaymentNotificationResultDTO struct {
+	OrderID string `json:"order_id"`
+	Status  string `json:"status"`
+	// Applied is false when the notification was a duplicate or arrived after the order moved on
+	Applied bool `json:"applied"`
+}
+
+// MaxOrderItemNotesLength bounds the free text a customer may attach to an order line
+const MaxOrderItemNotesLength = 255
+
 type OrderProductInfo struct {
 	ProductID string `json:"product_id"`
 	Quantity  int    `json:"quantity"`
+	Notes     string `json:"notes,omitempty"`
 }
 
 type OrderPanelDTO struct {
@@ -28,39 +58,198 @@ type OrderPanelDTO struct {
 }
 
 type OrderPanelItemDTO struct {
-	OrderNumber   string    `json:"order_number"`
-	Status        string    `json:"status"`
-	PreparingTime uint      `json:"preparing_time"`
-	CreatedAt     time.Time `json:"created_at"`
+	OrderNumber      string              `json:"order_number"`
+	Status           string              `json:"status"`
+	PreparingTime    uint                `json:"preparing_time"`
+	EstimatedReadyAt *time.Time          `json:"estimated_ready_at,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+	Items            []OrderPanelLineDTO `json:"items"`
+}
+
+type OrderPanelLineDTO struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+const (
+	PanelEventSnapshot     = "snapshot"
+	PanelEventOrderChanged = "order_changed"
+)
+
+// OrderPanelEventDTO is pushed to panel stream subscribers. A snapshot carries the whole panel,
+// an order_changed event carries only the order whose status changed.
+type OrderPanelEventDTO struct {
+	Type   string              `json:"type"`
+	Orders []OrderPanelItemDTO `json:"orders,omitempty"`
+	Order  *OrderPanelItemDTO  `json:"order,omitempty"`
 }
 
 type OrderDAO struct {
 	entity.Entity
-	CustomerID    string           `json:"customer_id" gorm:"index"`
-	Status        enum.OrderStatus `json:"status" gorm:"type:varchar(20)"`
-	Price         float64          `json:"price" gorm:"type:decimal(10,2)"`
-	PreparingTime uint             `json:"preparing_time" gorm:"type:integer"`
+	CustomerID         string           `json:"customer_id" gorm:"index"`
+	Status             enum.OrderStatus `json:"status" gorm:"type:varchar(20)"`
+	Price              money.Money      `json:"price" gorm:"type:decimal(10,2)" swaggertype:"number"`
+	Currency           money.Currency   `json:"currency" gorm:"type:varchar(3);default:BRL"`
+	PreparingTime      uint             `json:"preparing_time" gorm:"type:integer"`
+	CancellationReason string           `json:"cancellation_reason,omitempty" gorm:"type:text"`
+	PaymentID          string           `json:"payment_id,omitempty"`
+	QRCode             string           `json:"qr_code,omitempty" gorm:"type:text"`
+	QRCodeExpiresAt    *time.Time       `json:"qr_code_expires_at,omitempty"`
+	EstimatedReadyAt   *time.Time       `json:"estimated_ready_at,omitempty"`
+	StatusChangedAt    *time.Time       `json:"status_changed_at,omitempty"`
+	Items              []OrderItemDAO   `json:"items" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+}
+
+// OrderItemDAO has no currency column. Its UnitPrice is in the currency of its order, which is the only
+// currency of an order as enforced by entity.Order.FromDTO.
+type OrderItemDAO struct {
+	ID            string              `json:"id" gorm:"type:uuid;primaryKey"`
+	OrderID       string              `json:"order_id" gorm:"type:uuid;index"`
+	ProductID     string              `json:"product_id" gorm:"index"`
+	Name          string              `json:"name"`
+	UnitPrice     money.Money         `json:"unit_price" gorm:"type:decimal(10,2)" swaggertype:"number"`
+	Quantity      int                 `json:"quantity"`
+	Notes         string              `json:"notes,omitempty" gorm:"type:varchar(255)"`
+	Station       enum.KitchenStation `json:"station" gorm:"type:varchar(20)"`
+	PreparingTime uint                `json:"preparing_time" gorm:"type:integer"`
+}
+
+// currency falls back to the default for rows stored before the currency column existed
+func (o OrderDAO) currency() money.Currency {
+	if o.Currency == "" {
+		return money.DefaultCurrency
+	}
+	return o.Currency
+}
+
+func (OrderItemDAO) TableName() string {
+	return "order_items"
 }
 
 type OrderResponseListDTO struct {
-	Orders []OrderDAO `json:"orders"`
+	Orders     []OrderDAO     `json:"orders"`
+	Pagination *PaginationDTO `json:"pagination,omitempty"`
+}
+
+type PaginationDTO struct {
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalItems int64 `json:"total_items"`
+	TotalPages int   `json:"total_pages"`
+}
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// OrderSortFields maps the accepted sort_by values to their column names
+var OrderSortFields = map[string]string{
+	"created_at":     "created_at",
+	"updated_at":     "updated_at",
+	"price":          "price",
+	"status":         "status",
+	"preparing_time": "preparing_time",
+}
+
+type OrderFilterDTO struct {
+	ID          string       `form:"id"`
+	Page        int          `form:"page"`
+	PageSize    int          `form:"page_size"`
+	Status      []string     `form:"status"`
+	CustomerID  string       `form:"customer_id"`
+	CreatedFrom *time.Time   `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   *time.Time   `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinPrice    *money.Money `form:"min_price"`
+	MaxPrice    *money.Money `form:"max_price"`
+	SortBy      string       `form:"sort_by"`
+	SortOrder   string       `form:"sort_order"`
+}
+
+// OrderStatusCountDAO is the number of orders currently in a status
+type OrderStatusCountDAO struct {
+	Status enum.OrderStatus
+	Count  int64
+}
+
+// StationWorkDAO is the minutes of work queued on a kitchen station
+type StationWorkDAO struct {
+	Station enum.KitchenStation
+	Work    int64
+}
+
+type OrderStatusHistoryDAO struct {
+	ID         string           `json:"id" gorm:"type:uuid;primaryKey"`
+	OrderID    string           `json:"order_id" gorm:"type:uuid;index"`
+	FromStatus enum.OrderStatus `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   enum.OrderStatus `json:"to_status" gorm:"type:varchar(20)"`
+	ChangedBy  string           `json:"changed_by"`
+	ChangedAt  time.Time        `json:"changed_at" gorm:"index"`
+}
+
+func (OrderStatusHistoryDAO) TableName() string {
+	return "order_status_history"
+}
+
+type OutboxEntryDAO struct {
+	ID            string               `json:"id" gorm:"type:uuid;primaryKey"`
+	OrderID       string               `json:"order_id" gorm:"type:uuid;index"`
+	Type          enum.OutboxEventType `json:"type" gorm:"type:varchar(50)"`
+	Payload       string               `json:"payload" gorm:"type:jsonb"`
+	Status        enum.OutboxStatus    `json:"status" gorm:"type:varchar(20);index:idx_order_outbox_due,priority:1"`
+	Attempts      int                  `json:"attempts"`
+	NextAttemptAt time.Time            `json:"next_attempt_at" gorm:"index:idx_order_outbox_due,priority:2"`
+	LastError     string               `json:"last_error" gorm:"type:text"`
+	DeliveredAt   *time.Time           `json:"delivered_at"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+func (OutboxEntryDAO) TableName() string {
+	return "order_outbox"
+}
+
+type OutboxEntryListDTO struct {
+	Entries []OutboxEntryDAO `json:"entries"`
+}
+
+type OrderStatusHistoryResponseDTO struct {
+	OrderID string                  `json:"order_id"`
+	History []OrderStatusHistoryDAO `json:"history"`
 }
 
 type ProductDTO struct {
 	ID            string
-	Price         float64
+	Price         money.Money
 	PreparingTime uint
 }
 
 type ProductOrderDTO struct{}
 
-type PaymentDTO struct{ QrCode string }
+type PaymentDTO struct {
+	OrderID   string     `json:"order_id"`
+	PaymentID string     `json:"payment_id"`
+	QrCode    string     `json:"qr_code"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Pending is true while the payment has not been created by the payment service yet
+	Pending bool `json:"pending"`
+}
+
+type CreateOrderResponseDTO struct {
+	Message string `json:"message"`
+	PaymentDTO
+}
 
+// Validate checks the requested products and trims their notes, which are limited in characters as
+// stored in the order_items table
 func (c *CreateOrderDTO) Validate() error {
 	if len(c.Products) == 0 {
 		return errors.New("at least one product is required")
 	}
-	for _, v := range c.Products {
+	for i := range c.Products {
+		c.Products[i].Notes = strings.TrimSpace(c.Products[i].Notes)
+		v := c.Products[i]
 		if v.ProductID == "" {
 			return errors.New("products must not contain empty values")
 		}
@@ -68,30 +257,184 @@ func (c *CreateOrderDTO) Validate() error {
 		if v.Quantity <= 0 {
 			return errors.New("product quantity must be greater than zero")
 		}
+
+		if utf8.RuneCountInString(v.Notes) > MaxOrderItemNotesLength {
+			return fmt.Errorf("product notes must have at most %d characters", MaxOrderItemNotesLength)
+		}
+	}
+	return nil
+}
+
+// Normalize fills paging and sorting defaults and validates the filter values
+func (f *OrderFilterDTO) Normalize() error {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.PageSize <= 0 {
+		f.PageSize = DefaultPageSize
+	}
+	if f.PageSize > MaxPageSize {
+		return fmt.Errorf("page_size must not be greater than %d", MaxPageSize)
+	}
+
+	for _, status := range f.Status {
+		if !enum.OrderStatus(status).IsValid() {
+			return fmt.Errorf("invalid status filter %q", status)
+		}
+	}
+
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedFrom.After(*f.CreatedTo) {
+		return errors.New("created_from must be before created_to")
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && f.MinPrice.Compare(*f.MaxPrice) > 0 {
+		return errors.New("min_price must not be greater than max_price")
 	}
+
+	if f.SortBy == "" {
+		f.SortBy = "created_at"
+	}
+	if _, ok := OrderSortFields[f.SortBy]; !ok {
+		return fmt.Errorf("invalid sort_by %q", f.SortBy)
+	}
+
+	switch f.SortOrder {
+	case "":
+		f.SortOrder = "desc"
+	case "asc", "desc":
+	default:
+		return fmt.Errorf("invalid sort_order %q, use asc or desc", f.SortOrder)
+	}
+
 	return nil
 }
 
+func (f OrderFilterDTO) Offset() int {
+	return (f.Page - 1) * f.PageSize
+}
+
+func NewPaginationDTO(filter OrderFilterDTO, totalItems int64) PaginationDTO {
+	totalPages := 0
+	if filter.PageSize > 0 {
+		totalPages = int((totalItems + int64(filter.PageSize) - 1) / int64(filter.PageSize))
+	}
+	return PaginationDTO{
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		TotalItems: totalItems,
+		TotalPages: totalPages,
+	}
+}
+
+func ToOrderPanelItemDTO(order OrderDAO) OrderPanelItemDTO {
+	lines := make([]OrderPanelLineDTO, 0, len(order.Items))
+	for _, item := range order.Items {
+		lines = append(lines, OrderPanelLineDTO{
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Notes:    item.Notes,
+		})
+	}
+
+	return OrderPanelItemDTO{
+		OrderNumber:      order.Entity.ID[len(order.Entity.ID)-4:],
+		Status:           string(order.Status),
+		PreparingTime:    order.PreparingTime,
+		EstimatedReadyAt: order.EstimatedReadyAt,
+		CreatedAt:        order.CreatedAt,
+		Items:            lines,
+	}
+}
+
+func ToOrderPanelDTO(orders []OrderDAO) OrderPanelDTO {
+	panel := OrderPanelDTO{Orders: []OrderPanelItemDTO{}}
+	for _, order := range orders {
+		panel.Orders = append(panel.Orders, ToOrderPanelItemDTO(order))
+	}
+	return panel
+}
+
+func ToPaymentDTO(order OrderDAO) PaymentDTO {
+	return PaymentDTO{
+		OrderID:   order.ID,
+		PaymentID: order.PaymentID,
+		QrCode:    order.QRCode,
+		ExpiresAt: order.QRCodeExpiresAt,
+		Pending:   order.PaymentID == "",
+	}
+}
+
 func ToOrderDAO(order orderentity.Order) OrderDAO {
 	return OrderDAO{
-		Entity:        order.Entity,
-		CustomerID:    order.CustomerID,
-		Status:        order.Status,
-		Price:         order.Price,
-		PreparingTime: order.PreparingTime,
+		Entity:             order.Entity,
+		CustomerID:         order.CustomerID,
+		Status:             order.Status,
+		Price:              order.Price,
+		Currency:           order.Price.Currency(),
+		PreparingTime:      order.PreparingTime,
+		CancellationReason: order.CancellationReason,
+		PaymentID:          order.PaymentID,
+		QRCode:             order.QRCode,
+		QRCodeExpiresAt:    order.QRCodeExpiresAt,
+		EstimatedReadyAt:   order.EstimatedReadyAt,
+		StatusChangedAt:    order.StatusChangedAt,
+		Items:              ToOrderItemDAOList(order.Items),
 	}
 }
 
 func FromOrderDAO(dao OrderDAO) orderentity.Order {
 	return orderentity.Order{
-		Entity:        dao.Entity,
-		CustomerID:    dao.CustomerID,
-		Status:        dao.Status,
-		Price:         dao.Price,
-		PreparingTime: dao.PreparingTime,
+		Entity:             dao.Entity,
+		CustomerID:         dao.CustomerID,
+		Status:             dao.Status,
+		Price:              dao.Price.WithCurrency(dao.currency()),
+		PreparingTime:      dao.PreparingTime,
+		CancellationReason: dao.CancellationReason,
+		PaymentID:          dao.PaymentID,
+		QRCode:             dao.QRCode,
+		QRCodeExpiresAt:    dao.QRCodeExpiresAt,
+		EstimatedReadyAt:   dao.EstimatedReadyAt,
+		StatusChangedAt:    dao.StatusChangedAt,
+		Items:              OrderItemEntityListFromDAOList(dao.Items, dao.currency()),
 	}
 }
 
+func ToOrderItemDAOList(items []orderentity.OrderItem) []OrderItemDAO {
+	daoList := make([]OrderItemDAO, 0, len(items))
+	for _, item := range items {
+		daoList = append(daoList, OrderItemDAO{
+			ID:            item.ID,
+			OrderID:       item.OrderID,
+			ProductID:     item.ProductID,
+			Name:          item.Name,
+			UnitPrice:     item.UnitPrice,
+			Quantity:      item.Quantity,
+			Notes:         item.Notes,
+			Station:       item.Station,
+			PreparingTime: item.PreparingTime,
+		})
+	}
+	return daoList
+}
+
+// OrderItemEntityListFromDAOList converts stored items, whose prices are in the currency of their order
+func OrderItemEntityListFromDAOList(daoList []OrderItemDAO, currency money.Currency) []orderentity.OrderItem {
+	items := make([]orderentity.OrderItem, 0, len(daoList))
+	for _, dao := range daoList {
+		items = append(items, orderentity.OrderItem{
+			ID:            dao.ID,
+			OrderID:       dao.OrderID,
+			ProductID:     dao.ProductID,
+			Name:          dao.Name,
+			UnitPrice:     dao.UnitPrice.WithCurrency(currency),
+			Quantity:      dao.Quantity,
+			Notes:         dao.Notes,
+			Station:       dao.Station,
+			PreparingTime: dao.PreparingTime,
+		})
+	}
+	return items
+}
+
 func FromCreateOrderDTO(dto CreateOrderDTO) orderentity.Order {
 	return orderentity.Order{
 		CustomerID: dto.CustomerID,
@@ -106,3 +449,81 @@ func EntityListFromDAOList(daoList []OrderDAO) []orderentity.Order {
 	}
 	return orders
 }
+
+func ToOrderStatusHistoryDAO(history orderentity.OrderStatusHistory) OrderStatusHistoryDAO {
+	return OrderStatusHistoryDAO{
+		ID:         history.ID,
+		OrderID:    history.OrderID,
+		FromStatus: history.FromStatus,
+		ToStatus:   history.ToStatus,
+		ChangedBy:  history.ChangedBy,
+		ChangedAt:  history.ChangedAt,
+	}
+}
+
+func FromOrderStatusHistoryDAO(dao OrderStatusHistoryDAO) orderentity.OrderStatusHistory {
+	return orderentity.OrderStatusHistory{
+		ID:         dao.ID,
+		OrderID:    dao.OrderID,
+		FromStatus: dao.FromStatus,
+		ToStatus:   dao.ToStatus,
+		ChangedBy:  dao.ChangedBy,
+		ChangedAt:  dao.ChangedAt,
+	}
+}
+
+func HistoryEntityListFromDAOList(daoList []OrderStatusHistoryDAO) []orderentity.OrderStatusHistory {
+	history := make([]orderentity.OrderStatusHistory, 0, len(daoList))
+	for _, dao := range daoList {
+		history = append(history, FromOrderStatusHistoryDAO(dao))
+	}
+	return history
+}
+
+func ToOutboxEntryDAO(entry orderentity.OutboxEntry) OutboxEntryDAO {
+	return OutboxEntryDAO{
+		ID:            entry.ID,
+		OrderID:       entry.OrderID,
+		Type:          entry.Type,
+		Payload:       entry.Payload,
+		Status:        entry.Status,
+		Attempts:      entry.Attempts,
+		NextAttemptAt: entry.NextAttemptAt,
+		LastError:     entry.LastError,
+		DeliveredAt:   entry.DeliveredAt,
+		CreatedAt:     entry.CreatedAt,
+		UpdatedAt:     entry.UpdatedAt,
+	}
+}
+
+func FromOutboxEntryDAO(dao OutboxEntryDAO) orderentity.OutboxEntry {
+	return orderentity.OutboxEntry{
+		ID:            dao.ID,
+		OrderID:       dao.OrderID,
+		Type:          dao.Type,
+		Payload:       dao.Payload,
+		Status:        dao.Status,
+		Attempts:      dao.Attempts,
+		NextAttemptAt: dao.NextAttemptAt,
+		LastError:     dao.LastError,
+		DeliveredAt:   dao.DeliveredAt,
+		CreatedAt:     dao.CreatedAt,
+		UpdatedAt:     dao.UpdatedAt,
+	}
+}
+
+func ToOutboxEntryDAOList(entries []orderentity.OutboxEntry) []OutboxEntryDAO {
+	daoList := make([]OutboxEntryDAO, 0, len(entries))
+	for _, entry := range entries {
+		daoList = append(daoList, ToOutboxEntryDAO(entry))
+	}
+	return daoList
+}
+
+func OutboxEntityListFromDAOList(daoList []OutboxEntryDAO) []orderentity.OutboxEntry {
+	entries := make([]orderentity.OutboxEntry, 0, len(daoList))
+	for _, dao := range daoList {
+		entries = append(entries, FromOutboxEntryDAO(dao))
+	}
+	return entries
+}
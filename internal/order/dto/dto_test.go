@@ -0,0 +1,104 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderFilterDTO_Normalize(t *testing.T) {
+	from := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+	minPrice, maxPrice := money.FromCents(5000), money.FromCents(1000)
+
+	tests := []struct {
+		name    string
+		filter  OrderFilterDTO
+		want    OrderFilterDTO
+		wantErr string
+	}{
+		{
+			name:   "empty filter gets defaults",
+			filter: OrderFilterDTO{},
+			want:   OrderFilterDTO{Page: 1, PageSize: DefaultPageSize, SortBy: "created_at", SortOrder: "desc"},
+		},
+		{
+			name:   "explicit values are kept",
+			filter: OrderFilterDTO{Page: 3, PageSize: 50, Status: []string{"ready"}, SortBy: "price", SortOrder: "asc"},
+			want:   OrderFilterDTO{Page: 3, PageSize: 50, Status: []string{"ready"}, SortBy: "price", SortOrder: "asc"},
+		},
+		{
+			name:    "page size above limit",
+			filter:  OrderFilterDTO{PageSize: MaxPageSize + 1},
+			wantErr: "page_size must not be greater than 100",
+		},
+		{
+			name:    "unknown status",
+			filter:  OrderFilterDTO{Status: []string{"banana"}},
+			wantErr: `invalid status filter "banana"`,
+		},
+		{
+			name:    "inverted date range",
+			filter:  OrderFilterDTO{CreatedFrom: &from, CreatedTo: &to},
+			wantErr: "created_from must be before created_to",
+		},
+		{
+			name:    "inverted price range",
+			filter:  OrderFilterDTO{MinPrice: &minPrice, MaxPrice: &maxPrice},
+			wantErr: "min_price must not be greater than max_price",
+		},
+		{
+			name:    "unknown sort field",
+			filter:  OrderFilterDTO{SortBy: "customer_id; drop table orders"},
+			wantErr: `invalid sort_by "customer_id; drop table orders"`,
+		},
+		{
+			name:    "unknown sort order",
+			filter:  OrderFilterDTO{SortOrder: "up"},
+			wantErr: `invalid sort_order "up", use asc or desc`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Normalize()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.filter)
+		})
+	}
+}
+
+func TestCreateOrderDTO_Validate_Notes(t *testing.T) {
+	tests := []struct {
+		name      string
+		notes     string
+		wantNotes string
+		wantErr   string
+	}{
+		{name: "notes are trimmed", notes: "  sem cebola \n", wantNotes: "sem cebola"},
+		{name: "limit counts characters", notes: strings.Repeat("ç", MaxOrderItemNotesLength), wantNotes: strings.Repeat("ç", MaxOrderItemNotesLength)},
+		{name: "surrounding spaces do not count", notes: " " + strings.Repeat("a", MaxOrderItemNotesLength) + " ", wantNotes: strings.Repeat("a", MaxOrderItemNotesLength)},
+		{name: "notes above the limit", notes: strings.Repeat("a", MaxOrderItemNotesLength+1), wantErr: "product notes must have at most 255 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := CreateOrderDTO{Products: []OrderProductInfo{{ProductID: "1", Quantity: 1, Notes: tt.notes}}}
+
+			err := order.Validate()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantNotes, order.Products[0].Notes)
+		})
+	}
+}
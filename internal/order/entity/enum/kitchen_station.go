@@ -0,0 +1,29 @@
+package enum
+
+type KitchenStation string
+
+const (
+	KitchenStationGrill    KitchenStation = "grill"
+	KitchenStationFryer    KitchenStation = "fryer"
+	KitchenStationDrinks   KitchenStation = "drinks"
+	KitchenStationAssembly KitchenStation = "assembly"
+)
+
+var KitchenStationMapper = map[string]KitchenStation{
+	KitchenStationGrill.String():    KitchenStationGrill,
+	KitchenStationFryer.String():    KitchenStationFryer,
+	KitchenStationDrinks.String():   KitchenStationDrinks,
+	KitchenStationAssembly.String(): KitchenStationAssembly,
+}
+
+// ParseKitchenStation maps a product station to a known one, falling back to assembly
+func ParseKitchenStation(station string) KitchenStation {
+	if known, ok := KitchenStationMapper[station]; ok {
+		return known
+	}
+	return KitchenStationAssembly
+}
+
+func (k KitchenStation) String() string {
+	return string(k)
+}
@@ -1,5 +1,10 @@
 package enum
 
+import (
+	"fmt"
+	"strings"
+)
+
 type OrderStatus string
 
 const (
@@ -8,6 +13,8 @@ const (
 	OrderStatusInPreparation   OrderStatus = "in_preparation"
 	OrderStatusReady           OrderStatus = "ready"
 	OrderStatusCompleted       OrderStatus = "completed"
+	OrderStatusCancelled       OrderStatus = "cancelled"
+	OrderStatusPaymentRejected OrderStatus = "payment_rejected"
 )
 
 var OrderPanelStatus = []string{
@@ -22,8 +29,66 @@ var StatusMapper = map[string]OrderStatus{
 	OrderStatusInPreparation.String():   OrderStatusInPreparation,
 	OrderStatusReady.String():           OrderStatusReady,
 	OrderStatusCompleted.String():       OrderStatusCompleted,
+	OrderStatusCancelled.String():       OrderStatusCancelled,
+	OrderStatusPaymentRejected.String(): OrderStatusPaymentRejected,
+}
+
+// StatusTransitions lists, for each status, the statuses an order may move to next.
+// Completed, cancelled and payment rejected orders are final and accept no further transitions.
+var StatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusAwaitingPayment: {OrderStatusReceived, OrderStatusCancelled, OrderStatusPaymentRejected},
+	OrderStatusReceived:        {OrderStatusInPreparation, OrderStatusCancelled},
+	OrderStatusInPreparation:   {OrderStatusReady, OrderStatusCancelled},
+	OrderStatusReady:           {OrderStatusCompleted},
+	OrderStatusCompleted:       {},
+	OrderStatusCancelled:       {},
+	OrderStatusPaymentRejected: {},
 }
 
 func (o OrderStatus) String() string {
 	return string(o)
 }
+
+func (o OrderStatus) IsValid() bool {
+	_, ok := StatusMapper[o.String()]
+	return ok
+}
+
+// NextStatuses returns the statuses reachable from the current one
+func (o OrderStatus) NextStatuses() []OrderStatus {
+	return StatusTransitions[o]
+}
+
+func (o OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range o.NextStatuses() {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition checks the move from the current status to next against StatusTransitions
+// and describes the allowed next statuses when it is rejected.
+func (o OrderStatus) ValidateTransition(next OrderStatus) error {
+	if !next.IsValid() {
+		return fmt.Errorf("invalid order status %q", next)
+	}
+
+	if o.CanTransitionTo(next) {
+		return nil
+	}
+
+	allowed := o.NextStatuses()
+	if len(allowed) == 0 {
+		return fmt.Errorf("order in status %q cannot be changed", o)
+	}
+
+	names := make([]string, 0, len(allowed))
+	for _, status := range allowed {
+		names = append(names, status.String())
+	}
+
+	return fmt.Errorf("cannot change order status from %q to %q, allowed next statuses: %s",
+		o, next, strings.Join(names, ", "))
+}
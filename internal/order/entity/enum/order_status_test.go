@@ -0,0 +1,77 @@
+package enum
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderStatus_ValidateTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    OrderStatus
+		to      OrderStatus
+		wantErr string
+	}{
+		{
+			name: "awaiting payment to received",
+			from: OrderStatusAwaitingPayment,
+			to:   OrderStatusReceived,
+		},
+		{
+			name: "received to in preparation",
+			from: OrderStatusReceived,
+			to:   OrderStatusInPreparation,
+		},
+		{
+			name: "in preparation to ready",
+			from: OrderStatusInPreparation,
+			to:   OrderStatusReady,
+		},
+		{
+			name: "ready to completed",
+			from: OrderStatusReady,
+			to:   OrderStatusCompleted,
+		},
+		{
+			name: "in preparation to cancelled",
+			from: OrderStatusInPreparation,
+			to:   OrderStatusCancelled,
+		},
+		{
+			name:    "skipping states is rejected",
+			from:    OrderStatusAwaitingPayment,
+			to:      OrderStatusCompleted,
+			wantErr: `cannot change order status from "awaiting_payment" to "completed", allowed next statuses: received, cancelled, payment_rejected`,
+		},
+		{
+			name:    "unknown status is rejected",
+			from:    OrderStatusReceived,
+			to:      OrderStatus("banana"),
+			wantErr: `invalid order status "banana"`,
+		},
+		{
+			name:    "same status is rejected",
+			from:    OrderStatusReady,
+			to:      OrderStatusReady,
+			wantErr: `cannot change order status from "ready" to "ready", allowed next statuses: completed`,
+		},
+		{
+			name:    "final status is rejected",
+			from:    OrderStatusCompleted,
+			to:      OrderStatusCancelled,
+			wantErr: `order in status "completed" cannot be changed`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.from.ValidateTransition(tt.to)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
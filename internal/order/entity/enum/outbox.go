@@ -0,0 +1,27 @@
+package enum
+
+type OutboxEventType string
+
+const (
+	OutboxEventCreateProductOrders OutboxEventType = "product_order.create_bulk"
+	OutboxEventCreatePayment       OutboxEventType = "payment.create"
+)
+
+func (o OutboxEventType) String() string {
+	return string(o)
+}
+
+type OutboxStatus string
+
+const (
+	// OutboxStatusPending entries are waiting for their first or next delivery attempt
+	OutboxStatusPending OutboxStatus = "pending"
+	// OutboxStatusDelivered entries were accepted by the downstream service
+	OutboxStatusDelivered OutboxStatus = "delivered"
+	// OutboxStatusFailed entries ran out of attempts and need an admin to retry them
+	OutboxStatusFailed OutboxStatus = "failed"
+)
+
+func (o OutboxStatus) String() string {
+	return string(o)
+}
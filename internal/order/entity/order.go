@@ -5,66 +5,172 @@ import (
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/money"
 	"github.com/google/uuid"
 )
 
 type Order struct {
 	entity.Entity
-	CustomerID    string           `json:"customer_id" gorm:"index"`
-	Status        enum.OrderStatus `json:"status" gorm:"type:varchar(20)"`
-	Price         float64          `json:"price" gorm:"type:decimal(10,2)"`
-	PreparingTime uint             `json:"preparing_time" gorm:"type:integer"`
+	CustomerID         string           `json:"customer_id" gorm:"index"`
+	Status             enum.OrderStatus `json:"status" gorm:"type:varchar(20)"`
+	Price              money.Money      `json:"price" gorm:"type:decimal(10,2)"`
+	PreparingTime      uint             `json:"preparing_time" gorm:"type:integer"`
+	CancellationReason string           `json:"cancellation_reason,omitempty" gorm:"type:text"`
+	PaymentID          string           `json:"payment_id,omitempty"`
+	QRCode             string           `json:"qr_code,omitempty" gorm:"type:text"`
+	QRCodeExpiresAt    *time.Time       `json:"qr_code_expires_at,omitempty"`
+	EstimatedReadyAt   *time.Time       `json:"estimated_ready_at,omitempty"`
+	StatusChangedAt    *time.Time       `json:"status_changed_at,omitempty"`
+	Items              []OrderItem      `json:"items"`
+}
+
+// OrderItem is an order line with the product data as it was when the order was placed. Station and
+// PreparingTime are kept to estimate the kitchen load. UnitPrice is always in the currency of the order
+// Price, as FromDTO rejects products priced in different currencies.
+type OrderItem struct {
+	ID            string              `json:"id"`
+	OrderID       string              `json:"order_id"`
+	ProductID     string              `json:"product_id"`
+	Name          string              `json:"name"`
+	UnitPrice     money.Money         `json:"unit_price"`
+	Quantity      int                 `json:"quantity"`
+	Notes         string              `json:"notes,omitempty"`
+	Station       enum.KitchenStation `json:"station"`
+	PreparingTime uint                `json:"preparing_time"`
+}
+
+// Payment is the payment created for an order by the payment service
+type Payment struct {
+	ID        string
+	QRCode    string
+	ExpiresAt *time.Time
 }
 
 func (o Order) Build() Order {
+	orderID := uuid.NewString()
+	now := time.Now()
+
+	items := make([]OrderItem, 0, len(o.Items))
+	for _, item := range o.Items {
+		item.ID = uuid.NewString()
+		item.OrderID = orderID
+		items = append(items, item)
+	}
+
 	return Order{
 		Entity: entity.Entity{
-			ID:        uuid.NewString(),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			ID:        orderID,
+			CreatedAt: now,
+			UpdatedAt: now,
 		},
-		CustomerID:    o.CustomerID,
-		Status:        o.Status,
-		Price:         o.Price,
-		PreparingTime: o.PreparingTime,
+		CustomerID:       o.CustomerID,
+		Status:           o.Status,
+		Price:            o.Price,
+		PreparingTime:    o.PreparingTime,
+		EstimatedReadyAt: o.EstimatedReadyAt,
+		StatusChangedAt:  &now,
+		Items:            items,
 	}
 }
 
+// StatusEnteredAt is when the order moved to its current status. Orders stored before the change
+// time was kept fall back to their creation time.
+func (o Order) StatusEnteredAt() time.Time {
+	if o.StatusChangedAt != nil {
+		return *o.StatusChangedAt
+	}
+	return o.CreatedAt
+}
+
+// ApplyEstimate sets the preparing time and the estimated ready time of the order from now
+func (o Order) ApplyEstimate(estimate PreparationEstimate, now time.Time) Order {
+	readyAt := now.Add(time.Duration(estimate.ReadyIn) * time.Minute)
+	o.PreparingTime = estimate.PreparingTime
+	o.EstimatedReadyAt = &readyAt
+	return o
+}
+
+// Cancel returns a copy of the order moved to the cancelled status with the given reason
+func (o Order) Cancel(reason string) Order {
+	o.Status = enum.OrderStatusCancelled
+	o.CancellationReason = reason
+	return o
+}
+
 type OrderProductInfo struct {
 	ProductID string `json:"product_id"`
 	Quantity  int    `json:"quantity"`
+	Notes     string `json:"notes,omitempty"`
 }
 
 // Product representa os dados básicos de um produto para cálculos de pedido
 type Product struct {
-	Id            string  `json:"id"`
-	Price         float64 `json:"price"`
-	PreparingTime uint    `json:"preparing_time"`
+	Id            string      `json:"id"`
+	Name          string      `json:"name"`
+	Price         money.Money `json:"price"`
+	PreparingTime uint        `json:"preparing_time"`
+	Station       string      `json:"station"`
 }
 
-func (o Order) FromDTO(customerID string, products []OrderProductInfo, allProducts []Product) Order {
-	totalPrice, preparingTime := o.getOrderInfoFromProducts(allProducts, products)
+// FromDTO builds a new order from the requested products. It fails when the product prices are not all
+// in the same currency, so an order and its items share one currency, or when the total overflows. The preparing time is left for a PreparationEstimate to fill.
+func (o Order) FromDTO(customerID string, products []OrderProductInfo, allProducts []Product) (Order, error) {
+	totalPrice, err := o.getTotalPriceFromProducts(allProducts, products)
+	if err != nil {
+		return Order{}, err
+	}
 
 	return Order{
-		CustomerID:    customerID,
-		Price:         totalPrice,
-		PreparingTime: preparingTime,
-		Status:        enum.OrderStatusAwaitingPayment,
+		CustomerID: customerID,
+		Price:      totalPrice,
+		Status:     enum.OrderStatusAwaitingPayment,
+		Items:      o.getOrderItemsFromProducts(allProducts, products),
+	}, nil
+}
+
+func (o Order) getOrderItemsFromProducts(products []Product, orderProducts []OrderProductInfo) []OrderItem {
+	items := make([]OrderItem, 0, len(orderProducts))
+
+	for _, item := range orderProducts {
+		for _, product := range products {
+			if product.Id == item.ProductID {
+				items = append(items, OrderItem{
+					ProductID:     product.Id,
+					Name:          product.Name,
+					UnitPrice:     product.Price,
+					Quantity:      item.Quantity,
+					Notes:         item.Notes,
+					Station:       enum.ParseKitchenStation(product.Station),
+					PreparingTime: product.PreparingTime,
+				})
+			}
+		}
 	}
+
+	return items
 }
 
-func (o Order) getOrderInfoFromProducts(products []Product, orderProducts []OrderProductInfo) (float64, uint) {
-	var totalPrice float64
-	var preparingTime uint
+func (o Order) getTotalPriceFromProducts(products []Product, orderProducts []OrderProductInfo) (money.Money, error) {
+	var totalPrice money.Money
+
+	if len(products) > 0 {
+		totalPrice = money.New(0, products[0].Price.Currency())
+	}
 
 	for _, item := range orderProducts {
 		for _, product := range products {
 			if product.Id == item.ProductID {
-				totalPrice += product.Price * float64(item.Quantity)
-				preparingTime += product.PreparingTime
+				lineTotal, err := product.Price.Multiply(int64(item.Quantity))
+				if err != nil {
+					return money.Money{}, err
+				}
+
+				if totalPrice, err = totalPrice.Add(lineTotal); err != nil {
+					return money.Money{}, err
+				}
 			}
 		}
 	}
 
-	return totalPrice, preparingTime
+	return totalPrice, nil
 }
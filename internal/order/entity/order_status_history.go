@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	"github.com/google/uuid"
+)
+
+// OrderStatusHistory records a single status change of an order and who performed it
+type OrderStatusHistory struct {
+	ID         string
+	OrderID    string
+	FromStatus enum.OrderStatus
+	ToStatus   enum.OrderStatus
+	ChangedBy  string
+	ChangedAt  time.Time
+}
+
+func (h OrderStatusHistory) Build() OrderStatusHistory {
+	return OrderStatusHistory{
+		ID:         uuid.NewString(),
+		OrderID:    h.OrderID,
+		FromStatus: h.FromStatus,
+		ToStatus:   h.ToStatus,
+		ChangedBy:  h.ChangedBy,
+		ChangedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrder_FromDTO(t *testing.T) {
+	burgerPrice, _ := money.Parse("19.90")
+	sodaPrice, _ := money.Parse("0.10")
+
+	tests := []struct {
+		name      string
+		products  []Product
+		requested []OrderProductInfo
+		wantPrice string
+		wantErr   error
+	}{
+		{
+			name:      "totals are exact",
+			products:  []Product{{Id: "burger", Price: burgerPrice}, {Id: "soda", Price: sodaPrice}},
+			requested: []OrderProductInfo{{ProductID: "burger", Quantity: 3}, {ProductID: "soda", Quantity: 3}},
+			wantPrice: "60.00",
+		},
+		{
+			name:      "mixed currencies are rejected",
+			products:  []Product{{Id: "burger", Price: burgerPrice}, {Id: "soda", Price: sodaPrice.WithCurrency("USD")}},
+			requested: []OrderProductInfo{{ProductID: "burger", Quantity: 1}, {ProductID: "soda", Quantity: 1}},
+			wantErr:   money.ErrCurrencyMismatch,
+		},
+		{
+			name:      "overflowing totals are rejected",
+			products:  []Product{{Id: "burger", Price: money.FromCents(math.MaxInt64 / 2)}},
+			requested: []OrderProductInfo{{ProductID: "burger", Quantity: 3}},
+			wantErr:   money.ErrOverflow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := Order{}.FromDTO("customer", tt.requested, tt.products)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPrice, order.Price.String())
+			assert.Equal(t, money.BRL, order.Price.Currency())
+			assert.Len(t, order.Items, len(tt.requested))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	"github.com/google/uuid"
+)
+
+const (
+	// OutboxMaxAttempts is the number of deliveries tried before an entry is marked as failed
+	OutboxMaxAttempts = 10
+	// OutboxLease is how long a claimed entry is hidden from other dispatchers while it is delivered
+	OutboxLease = time.Minute
+
+	outboxBaseBackoff = 5 * time.Second
+	outboxMaxBackoff  = 10 * time.Minute
+)
+
+// OutboxEntry is a downstream call recorded in the same transaction as the order that requires it
+type OutboxEntry struct {
+	ID            string
+	OrderID       string
+	Type          enum.OutboxEventType
+	Payload       string
+	Status        enum.OutboxStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	DeliveredAt   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Build creates a pending entry already leased to the caller, which is expected to try
+// delivering it right away. Dispatchers only pick it up once the lease expires.
+func (o OutboxEntry) Build() OutboxEntry {
+	now := time.Now()
+	return OutboxEntry{
+		ID:            uuid.NewString(),
+		OrderID:       o.OrderID,
+		Type:          o.Type,
+		Payload:       o.Payload,
+		Status:        enum.OutboxStatusPending,
+		NextAttemptAt: now.Add(OutboxLease),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+func (o OutboxEntry) MarkDelivered(now time.Time) OutboxEntry {
+	o.Status = enum.OutboxStatusDelivered
+	o.Attempts++
+	o.LastError = ""
+	o.DeliveredAt = &now
+	o.UpdatedAt = now
+	return o
+}
+
+// MarkAttemptFailed schedules the next attempt with exponential backoff, or marks the entry as
+// failed once OutboxMaxAttempts is reached
+func (o OutboxEntry) MarkAttemptFailed(now time.Time, cause error) OutboxEntry {
+	o.Attempts++
+	o.LastError = cause.Error()
+	o.UpdatedAt = now
+
+	if o.Attempts >= OutboxMaxAttempts {
+		o.Status = enum.OutboxStatusFailed
+		return o
+	}
+
+	backoff := outboxBaseBackoff << (o.Attempts - 1)
+	if backoff <= 0 || backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	o.NextAttemptAt = now.Add(backoff)
+	return o
+}
+
+// Requeue makes a failed entry eligible for immediate delivery with a fresh attempt budget
+func (o OutboxEntry) Requeue(now time.Time) OutboxEntry {
+	o.Status = enum.OutboxStatusPending
+	o.Attempts = 0
+	o.NextAttemptAt = now
+	o.UpdatedAt = now
+	return o
+}
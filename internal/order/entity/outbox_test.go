@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxEntry_MarkAttemptFailed(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cause := errors.New("payment service unavailable")
+
+	tests := []struct {
+		name         string
+		attempts     int
+		wantStatus   enum.OutboxStatus
+		wantNextWait time.Duration
+	}{
+		{"first failure waits the base backoff", 0, enum.OutboxStatusPending, 5 * time.Second},
+		{"backoff doubles on each failure", 3, enum.OutboxStatusPending, 40 * time.Second},
+		{"backoff is capped", 8, enum.OutboxStatusPending, 10 * time.Minute},
+		{"last attempt marks the entry as failed", OutboxMaxAttempts - 1, enum.OutboxStatusFailed, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := OutboxEntry{Status: enum.OutboxStatusPending, Attempts: tt.attempts}
+
+			got := entry.MarkAttemptFailed(now, cause)
+
+			assert.Equal(t, tt.attempts+1, got.Attempts)
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, cause.Error(), got.LastError)
+			if tt.wantStatus == enum.OutboxStatusPending {
+				assert.Equal(t, now.Add(tt.wantNextWait), got.NextAttemptAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+)
+
+// PreparationEstimatorConfig describes the kitchen modelled by a PreparationEstimator
+type PreparationEstimatorConfig struct {
+	// StationCapacity is how many items each station prepares at the same time. Stations left out
+	// prepare one item at a time.
+	StationCapacity map[enum.KitchenStation]uint
+}
+
+// StationWork is the minutes of work queued on each kitchen station
+type StationWork map[enum.KitchenStation]uint
+
+// PreparationEstimate holds preparation times in minutes
+type PreparationEstimate struct {
+	// PreparingTime is how long the order takes on an idle kitchen
+	PreparingTime uint
+	// ReadyIn also accounts for the orders already queued in the kitchen
+	ReadyIn uint
+}
+
+// PreparationEstimator models kitchen stations working in parallel. Each station splits its work
+// across its capacity, an item is never faster than its own preparing time, and an order is ready
+// when its slowest station finishes.
+type PreparationEstimator struct {
+	capacity map[enum.KitchenStation]uint
+}
+
+func NewPreparationEstimator(config PreparationEstimatorConfig) *PreparationEstimator {
+	capacity := make(map[enum.KitchenStation]uint, len(config.StationCapacity))
+	for station, items := range config.StationCapacity {
+		capacity[station] = items
+	}
+	return &PreparationEstimator{capacity: capacity}
+}
+
+// Estimate computes the preparation time of items, and when they would be ready behind the work
+// queued in the kitchen. Queued orders are counted with all their work, as the kitchen does not
+// report progress.
+func (e *PreparationEstimator) Estimate(items []OrderItem, queueWork StationWork) PreparationEstimate {
+	ownWork, longest := stationWork(items)
+
+	var estimate PreparationEstimate
+	for station, work := range ownWork {
+		capacity := e.capacityOf(station)
+
+		own := max(ceilDiv(work, capacity), longest[station])
+		withQueue := max(ceilDiv(work+queueWork[station], capacity), longest[station])
+
+		estimate.PreparingTime = max(estimate.PreparingTime, own)
+		estimate.ReadyIn = max(estimate.ReadyIn, withQueue)
+	}
+
+	return estimate
+}
+
+func (e *PreparationEstimator) capacityOf(station enum.KitchenStation) uint {
+	if capacity := e.capacity[station]; capacity > 0 {
+		return capacity
+	}
+	return 1
+}
+
+// stationWork sums the minutes of work of every station and tracks the longest single item on each
+func stationWork(items []OrderItem) (map[enum.KitchenStation]uint, map[enum.KitchenStation]uint) {
+	work := map[enum.KitchenStation]uint{}
+	longest := map[enum.KitchenStation]uint{}
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			continue
+		}
+		station := enum.ParseKitchenStation(item.Station.String())
+		work[station] += item.PreparingTime * uint(item.Quantity)
+		longest[station] = max(longest[station], item.PreparingTime)
+	}
+
+	return work, longest
+}
+
+func ceilDiv(value uint, divisor uint) uint {
+	return (value + divisor - 1) / divisor
+}
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreparationEstimator_Estimate(t *testing.T) {
+	burger := OrderItem{Station: enum.KitchenStationGrill, PreparingTime: 10, Quantity: 1}
+	fries := OrderItem{Station: enum.KitchenStationFryer, PreparingTime: 6, Quantity: 1}
+
+	capacity := map[enum.KitchenStation]uint{
+		enum.KitchenStationGrill: 2,
+		enum.KitchenStationFryer: 1,
+	}
+
+	withQuantity := func(item OrderItem, quantity int) OrderItem {
+		item.Quantity = quantity
+		return item
+	}
+
+	tests := []struct {
+		name  string
+		items []OrderItem
+		queue StationWork
+		want  PreparationEstimate
+	}{
+		{
+			name:  "stations work in parallel",
+			items: []OrderItem{burger, fries},
+			want:  PreparationEstimate{PreparingTime: 10, ReadyIn: 10},
+		},
+		{
+			name:  "quantity within station capacity",
+			items: []OrderItem{withQuantity(burger, 2)},
+			want:  PreparationEstimate{PreparingTime: 10, ReadyIn: 10},
+		},
+		{
+			name:  "quantity beyond station capacity",
+			items: []OrderItem{withQuantity(fries, 3)},
+			want:  PreparationEstimate{PreparingTime: 18, ReadyIn: 18},
+		},
+		{
+			name:  "queued orders delay the ready time",
+			items: []OrderItem{burger, fries},
+			queue: StationWork{enum.KitchenStationFryer: 12},
+			want:  PreparationEstimate{PreparingTime: 10, ReadyIn: 18},
+		},
+		{
+			name: "unknown station falls back to assembly",
+			items: []OrderItem{
+				{Station: enum.KitchenStation("oven"), PreparingTime: 4, Quantity: 2},
+			},
+			want: PreparationEstimate{PreparingTime: 8, ReadyIn: 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewPreparationEstimator(PreparationEstimatorConfig{StationCapacity: capacity}).Estimate(tt.items, tt.queue)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package broadcaster
+
+import (
+	"sync"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/dto"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/interfaces"
+)
+
+// DefaultBufferSize is the number of events a subscriber may lag behind before it is dropped
+const DefaultBufferSize = 32
+
+// Broadcaster fans order panel events out to every subscriber of the current instance.
+// Publishing never blocks: a subscriber whose buffer is full is dropped and its channel closed,
+// so slow panels reconnect and start again from a fresh snapshot.
+type Broadcaster struct {
+	mu          sync.RWMutex
+	subscribers map[uint64]chan dto.OrderPanelEventDTO
+	nextID      uint64
+	bufferSize  int
+	closed      bool
+}
+
+func New(bufferSize int) *Broadcaster {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &Broadcaster{
+		subscribers: make(map[uint64]chan dto.OrderPanelEventDTO),
+		bufferSize:  bufferSize,
+	}
+}
+
+var _ interfaces.OrderChangeNotifier = (*Broadcaster)(nil)
+
+// Subscribe registers a new subscriber. The returned function unsubscribes it and is safe to call
+// more than once, including after the subscriber was dropped.
+func (b *Broadcaster) Subscribe() (<-chan dto.OrderPanelEventDTO, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan dto.OrderPanelEventDTO, b.bufferSize)
+	if b.closed {
+		close(events)
+		return events, func() {}
+	}
+
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = events
+
+	return events, func() { b.remove(id) }
+}
+
+func (b *Broadcaster) Publish(event dto.OrderPanelEventDTO) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+			delete(b.subscribers, id)
+			close(events)
+		}
+	}
+}
+
+func (b *Broadcaster) NotifyOrderChanged(order entity.Order) {
+	item := dto.ToOrderPanelItemDTO(dto.ToOrderDAO(order))
+	b.Publish(dto.OrderPanelEventDTO{
+		Type:  dto.PanelEventOrderChanged,
+		Order: &item,
+	})
+}
+
+// SubscriberCount returns how many subscribers are currently connected
+func (b *Broadcaster) SubscriberCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.subscribers)
+}
+
+// Close drops every subscriber and rejects new ones
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for id, events := range b.subscribers {
+		delete(b.subscribers, id)
+		close(events)
+	}
+}
+
+func (b *Broadcaster) remove(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if events, ok := b.subscribers[id]; ok {
+		delete(b.subscribers, id)
+		close(events)
+	}
+}
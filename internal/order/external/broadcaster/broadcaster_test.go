@@ -0,0 +1,69 @@
+package broadcaster
+
+import (
+	"testing"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/dto"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	sharedentity "github.com/fiap-161/tc-golunch-operation-service/internal/shared/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcaster_NotifyOrderChanged(t *testing.T) {
+	b := New(4)
+	first, unsubscribeFirst := b.Subscribe()
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := b.Subscribe()
+	defer unsubscribeSecond()
+
+	b.NotifyOrderChanged(entity.Order{
+		Entity: sharedentity.Entity{ID: "7f1e2d3c-0000-0000-0000-00000000abcd"},
+		Status: enum.OrderStatusReady,
+	})
+
+	for _, events := range []<-chan dto.OrderPanelEventDTO{first, second} {
+		event := <-events
+		assert.Equal(t, dto.PanelEventOrderChanged, event.Type)
+		assert.Equal(t, "abcd", event.Order.OrderNumber)
+		assert.Equal(t, "ready", event.Order.Status)
+	}
+}
+
+func TestBroadcaster_DropsSlowSubscriber(t *testing.T) {
+	b := New(1)
+	slow, unsubscribeSlow := b.Subscribe()
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := b.Subscribe()
+	defer unsubscribeFast()
+
+	b.Publish(dto.OrderPanelEventDTO{Type: dto.PanelEventOrderChanged})
+	<-fast
+	b.Publish(dto.OrderPanelEventDTO{Type: dto.PanelEventOrderChanged})
+
+	assert.Equal(t, 1, b.SubscriberCount())
+
+	_, ok := <-slow
+	assert.True(t, ok, "buffered event is still delivered")
+	_, ok = <-slow
+	assert.False(t, ok, "channel is closed once the subscriber is dropped")
+
+	_, ok = <-fast
+	assert.True(t, ok)
+}
+
+func TestBroadcaster_Close(t *testing.T) {
+	b := New(1)
+	events, unsubscribe := b.Subscribe()
+
+	b.Close()
+	unsubscribe()
+
+	_, ok := <-events
+	assert.False(t, ok)
+
+	late, _ := b.Subscribe()
+	_, ok = <-late
+	assert.False(t, ok)
+	assert.Equal(t, 0, b.SubscriberCount())
+}
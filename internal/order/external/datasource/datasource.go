@@ -2,14 +2,24 @@ package datasource
 
 import (
 	"context"
+	"time"
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/dto"
 )
 
 type DataSource interface {
-	Create(ctx context.Context, order dto.OrderDAO) (dto.OrderDAO, error)
-	GetAll(ctx context.Context) ([]dto.OrderDAO, error)
+	Create(ctx context.Context, order dto.OrderDAO, history dto.OrderStatusHistoryDAO, outbox []dto.OutboxEntryDAO) (dto.OrderDAO, error)
+	GetAll(ctx context.Context, filter dto.OrderFilterDTO) ([]dto.OrderDAO, int64, error)
 	FindByID(ctx context.Context, id string) (dto.OrderDAO, error)
 	GetPanel(ctx context.Context) ([]dto.OrderDAO, error)
-	Update(ctx context.Context, order dto.OrderDAO) (dto.OrderDAO, error)
+	KitchenQueueWork(ctx context.Context, excludeOrderID string) ([]dto.StationWorkDAO, error)
+	Update(ctx context.Context, order dto.OrderDAO, history dto.OrderStatusHistoryDAO) (dto.OrderDAO, error)
+	UpdatePayment(ctx context.Context, orderID string, paymentID string, qrCode string, expiresAt *time.Time) error
+	GetStatusHistory(ctx context.Context, orderID string) ([]dto.OrderStatusHistoryDAO, error)
+	ClaimDueOutboxEntries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]dto.OutboxEntryDAO, error)
+	UpdateOutboxEntry(ctx context.Context, entry dto.OutboxEntryDAO) error
+	RequeueFailedOutboxEntry(ctx context.Context, entry dto.OutboxEntryDAO, now time.Time) (bool, error)
+	FindOutboxEntryByID(ctx context.Context, id string) (dto.OutboxEntryDAO, error)
+	GetStuckOutboxEntries(ctx context.Context) ([]dto.OutboxEntryDAO, error)
+	CountByStatus(ctx context.Context) ([]dto.OrderStatusCountDAO, error)
 }
@@ -2,9 +2,15 @@ package datasource
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/dto"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // DB interface defines the database operations needed
@@ -18,6 +24,9 @@ type DB interface {
 	Updates(values any) *gorm.DB
 	Save(value any) *gorm.DB
 	Order(value any) *gorm.DB
+	Transaction(fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error
+	Preload(query string, args ...any) *gorm.DB
+	WithContext(ctx context.Context) *gorm.DB
 }
 
 // GormDataSource implements DataSource interface using GORM
@@ -32,29 +41,79 @@ func New(db DB) DataSource {
 	}
 }
 
-func (g *GormDataSource) Create(ctx context.Context, order dto.OrderDAO) (dto.OrderDAO, error) {
-	tx := g.db.Create(&order)
-	if tx.Error != nil {
-		return dto.OrderDAO{}, tx.Error
+// Create saves the order together with its initial status history entry and the outbox entries for
+// its downstream calls in a single transaction
+func (g *GormDataSource) Create(ctx context.Context, order dto.OrderDAO, history dto.OrderStatusHistoryDAO, outbox []dto.OutboxEntryDAO) (dto.OrderDAO, error) {
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+
+		if len(outbox) == 0 {
+			return nil
+		}
+		return tx.Create(&outbox).Error
+	})
+	if err != nil {
+		return dto.OrderDAO{}, err
 	}
 
 	return order, nil
 }
 
-func (g *GormDataSource) GetAll(ctx context.Context) ([]dto.OrderDAO, error) {
+// GetAll returns one page of orders matching the filter along with the total number of matches.
+// The filter is expected to be normalized, so SortBy is always one of dto.OrderSortFields.
+func (g *GormDataSource) GetAll(ctx context.Context, filter dto.OrderFilterDTO) ([]dto.OrderDAO, int64, error) {
 	var orders []dto.OrderDAO
+	var total int64
 
-	if err := g.db.Find(&orders).Error; err != nil {
-		return nil, err
+	if err := applyOrderFilter(g.db.WithContext(ctx).Model(&dto.OrderDAO{}), filter).Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
 
-	return orders, nil
+	orderBy := fmt.Sprintf("%s %s, id %s", dto.OrderSortFields[filter.SortBy], filter.SortOrder, filter.SortOrder)
+	if err := applyOrderFilter(g.db.WithContext(ctx).Model(&dto.OrderDAO{}), filter).
+		Preload("Items").
+		Order(orderBy).
+		Limit(filter.PageSize).
+		Offset(filter.Offset()).
+		Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
+
+func applyOrderFilter(query *gorm.DB, filter dto.OrderFilterDTO) *gorm.DB {
+	if len(filter.Status) > 0 {
+		query = query.Where("status IN ?", filter.Status)
+	}
+	if filter.CustomerID != "" {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at <= ?", *filter.CreatedTo)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("price <= ?", *filter.MaxPrice)
+	}
+	return query
 }
 
 func (g *GormDataSource) FindByID(ctx context.Context, id string) (dto.OrderDAO, error) {
 	var order dto.OrderDAO
 
-	tx := g.db.First(&order, "id = ?", id)
+	tx := g.db.WithContext(ctx).Preload("Items").First(&order, "id = ?", id)
 	if tx.Error != nil {
 		return dto.OrderDAO{}, tx.Error
 	}
@@ -65,8 +124,13 @@ func (g *GormDataSource) FindByID(ctx context.Context, id string) (dto.OrderDAO,
 func (g *GormDataSource) GetPanel(ctx context.Context) ([]dto.OrderDAO, error) {
 	var orders []dto.OrderDAO
 
-	if err := g.db.
-		Where("status != ?", "completed").
+	if err := g.db.WithContext(ctx).
+		Preload("Items").
+		Where("status NOT IN ?", []string{
+			enum.OrderStatusCompleted.String(),
+			enum.OrderStatusCancelled.String(),
+			enum.OrderStatusPaymentRejected.String(),
+		}).
 		Order(`
 			CASE 
 				WHEN status = 'ready' THEN 1
@@ -83,11 +147,177 @@ func (g *GormDataSource) GetPanel(ctx context.Context) ([]dto.OrderDAO, error) {
 	return orders, nil
 }
 
-func (g *GormDataSource) Update(ctx context.Context, order dto.OrderDAO) (dto.OrderDAO, error) {
-	tx := g.db.Save(&order)
-	if tx.Error != nil {
-		return dto.OrderDAO{}, tx.Error
+// KitchenQueueWork sums the minutes of work of the items of the orders queued in the kitchen, per
+// station, leaving out excludeOrderID when set. An empty ID is not compared, as it is not a valid uuid.
+func (g *GormDataSource) KitchenQueueWork(ctx context.Context, excludeOrderID string) ([]dto.StationWorkDAO, error) {
+	var work []dto.StationWorkDAO
+
+	query := g.db.WithContext(ctx).Model(&dto.OrderItemDAO{}).
+		Select("order_items.station, SUM(order_items.preparing_time * order_items.quantity) AS work").
+		Joins("JOIN order_daos ON order_daos.id = order_items.order_id").
+		Where("order_daos.status IN ? AND order_items.quantity > 0", []string{
+			enum.OrderStatusReceived.String(),
+			enum.OrderStatusInPreparation.String(),
+		})
+	if excludeOrderID != "" {
+		query = query.Where("order_daos.id <> ?", excludeOrderID)
+	}
+
+	if err := query.Group("order_items.station").Scan(&work).Error; err != nil {
+		return nil, err
+	}
+
+	return work, nil
+}
+
+// ErrStatusChanged is returned by Update when the order is no longer in the status the change was
+// validated against, because a concurrent change got there first
+var ErrStatusChanged = errors.New("order status changed concurrently")
+
+// orderStatusColumns are the columns a status change may write. Items and payment data are left
+// untouched, so a status change never overwrites a concurrent payment update.
+var orderStatusColumns = []string{"status", "cancellation_reason", "preparing_time", "estimated_ready_at", "status_changed_at", "updated_at"}
+
+// Update moves the order from history.FromStatus to its new status and appends the history entry in a
+// single transaction. Nothing is written and ErrStatusChanged is returned when the stored order is no
+// longer in history.FromStatus.
+func (g *GormDataSource) Update(ctx context.Context, order dto.OrderDAO, history dto.OrderStatusHistoryDAO) (dto.OrderDAO, error) {
+	order.UpdatedAt = time.Now()
+
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&dto.OrderDAO{}).
+			Where("id = ? AND status = ?", order.ID, history.FromStatus).
+			Select(orderStatusColumns).
+			Updates(&order)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+
+		return tx.Create(&history).Error
+	})
+	if err != nil {
+		return dto.OrderDAO{}, err
 	}
 
 	return order, nil
 }
+
+// UpdatePayment stores the payment data only, so it never overwrites a concurrent status change
+func (g *GormDataSource) UpdatePayment(ctx context.Context, orderID string, paymentID string, qrCode string, expiresAt *time.Time) error {
+	return g.db.WithContext(ctx).Model(&dto.OrderDAO{}).
+		Where("id = ?", orderID).
+		Updates(map[string]any{
+			"payment_id":         paymentID,
+			"qr_code":            qrCode,
+			"qr_code_expires_at": expiresAt,
+			"updated_at":         time.Now(),
+		}).Error
+}
+
+func (g *GormDataSource) GetStatusHistory(ctx context.Context, orderID string) ([]dto.OrderStatusHistoryDAO, error) {
+	var history []dto.OrderStatusHistoryDAO
+
+	if err := g.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("changed_at ASC").
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// ClaimDueOutboxEntries locks pending entries whose next attempt is due and pushes their next attempt
+// past the lease, so concurrent dispatchers, even on other instances, do not deliver them twice
+func (g *GormDataSource) ClaimDueOutboxEntries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]dto.OutboxEntryDAO, error) {
+	var entries []dto.OutboxEntryDAO
+
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", enum.OutboxStatusPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+
+		if len(entries) == 0 {
+			return nil
+		}
+
+		ids := make([]string, 0, len(entries))
+		for i := range entries {
+			ids = append(ids, entries[i].ID)
+			entries[i].NextAttemptAt = now.Add(lease)
+		}
+
+		return tx.Model(&dto.OutboxEntryDAO{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (g *GormDataSource) UpdateOutboxEntry(ctx context.Context, entry dto.OutboxEntryDAO) error {
+	return g.db.WithContext(ctx).Save(&entry).Error
+}
+
+// RequeueFailedOutboxEntry saves the requeued entry only while it is still failed and no dispatcher
+// holds its lease, and reports whether it was saved
+func (g *GormDataSource) RequeueFailedOutboxEntry(ctx context.Context, entry dto.OutboxEntryDAO, now time.Time) (bool, error) {
+	result := g.db.WithContext(ctx).Model(&dto.OutboxEntryDAO{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", entry.ID, enum.OutboxStatusFailed, now).
+		Select("status", "attempts", "next_attempt_at", "updated_at").
+		Updates(&entry)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (g *GormDataSource) FindOutboxEntryByID(ctx context.Context, id string) (dto.OutboxEntryDAO, error) {
+	var entry dto.OutboxEntryDAO
+
+	tx := g.db.WithContext(ctx).First(&entry, "id = ?", id)
+	if tx.Error != nil {
+		return dto.OutboxEntryDAO{}, tx.Error
+	}
+
+	return entry, nil
+}
+
+// GetStuckOutboxEntries returns failed entries and pending entries that already failed at least once
+func (g *GormDataSource) GetStuckOutboxEntries(ctx context.Context) ([]dto.OutboxEntryDAO, error) {
+	var entries []dto.OutboxEntryDAO
+
+	if err := g.db.WithContext(ctx).
+		Where("status = ? OR (status = ? AND attempts > 0)", enum.OutboxStatusFailed, enum.OutboxStatusPending).
+		Order("created_at ASC").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (g *GormDataSource) CountByStatus(ctx context.Context) ([]dto.OrderStatusCountDAO, error) {
+	var counts []dto.OrderStatusCountDAO
+
+	if err := g.db.WithContext(ctx).Model(&dto.OrderDAO{}).
+		Select("status, count(*) AS count").
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,287 @@
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/dto"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/entity"
+)
+
+// fakeResult is what the fake database answers to a statement
+type fakeResult struct {
+	columns      []string
+	rows         [][]driver.Value
+	rowsAffected int64
+}
+
+// fakeDB is a database/sql connector that records every statement and answers it with respond,
+// so the SQL built by the datasource can be checked without a database
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []string
+	respond    func(query string) fakeResult
+}
+
+func newFakeGorm(t *testing.T, respond func(query string) fakeResult) (*gorm.DB, *fakeDB) {
+	fake := &fakeDB{respond: respond}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fake)}), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	return db, fake
+}
+
+func (f *fakeDB) Statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.statements...)
+}
+
+func (f *fakeDB) answer(query string) fakeResult {
+	f.mu.Lock()
+	f.statements = append(f.statements, query)
+	f.mu.Unlock()
+
+	if f.respond == nil {
+		return fakeResult{}
+	}
+	return f.respond(query)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return c, nil }
+func (c fakeConn) Commit() error                       { c.db.answer("COMMIT"); return nil }
+func (c fakeConn) Rollback() error                     { c.db.answer("ROLLBACK"); return nil }
+
+func (c fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(c.db.answer(query).rowsAffected), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	result := c.db.answer(query)
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestGormDataSource_Update(t *testing.T) {
+	order := dto.OrderDAO{
+		Entity:    entity.Entity{ID: "order-1"},
+		Status:    enum.OrderStatusReady,
+		PaymentID: "stale-payment",
+	}
+	history := dto.OrderStatusHistoryDAO{
+		ID:         "history-1",
+		OrderID:    "order-1",
+		FromStatus: enum.OrderStatusInPreparation,
+		ToStatus:   enum.OrderStatusReady,
+	}
+
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		wantErr      error
+		wantHistory  bool
+	}{
+		{name: "order still in the expected status", rowsAffected: 1, wantHistory: true},
+		{name: "order changed concurrently", rowsAffected: 0, wantErr: ErrStatusChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeGorm(t, func(query string) fakeResult {
+				return fakeResult{rowsAffected: tt.rowsAffected}
+			})
+
+			_, err := New(db).Update(context.Background(), order, history)
+			assert.Equal(t, tt.wantErr, err)
+
+			statements := fake.Statements()
+			update := statements[0]
+			assert.True(t, strings.HasPrefix(update, `UPDATE "order_daos" SET`), update)
+			assert.Contains(t, update, "WHERE id = $7 AND status = $8")
+			assert.NotContains(t, update, "payment_id")
+
+			var insertedHistory bool
+			for _, statement := range statements {
+				insertedHistory = insertedHistory || strings.HasPrefix(statement, `INSERT INTO "order_status_history"`)
+			}
+			assert.Equal(t, tt.wantHistory, insertedHistory)
+		})
+	}
+}
+
+func TestGormDataSource_StatusHistory(t *testing.T) {
+	changedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	db, fake := newFakeGorm(t, func(query string) fakeResult {
+		if strings.HasPrefix(query, `SELECT * FROM "order_status_history"`) {
+			return fakeResult{
+				columns: []string{"id", "order_id", "from_status", "to_status", "changed_by", "changed_at"},
+				rows:    [][]driver.Value{{"history-1", "order-1", "", "awaiting_payment", "customer-1", changedAt}},
+			}
+		}
+		return fakeResult{rowsAffected: 1}
+	})
+	ds := New(db)
+
+	_, err := ds.Create(context.Background(),
+		dto.OrderDAO{
+			Entity: entity.Entity{ID: "order-1"},
+			Status: enum.OrderStatusAwaitingPayment,
+			Items:  []dto.OrderItemDAO{{ID: "item-1", ProductID: "burger", Quantity: 1, Notes: "sem cebola"}},
+		},
+		dto.OrderStatusHistoryDAO{ID: "history-1", OrderID: "order-1", ToStatus: enum.OrderStatusAwaitingPayment},
+		nil)
+	assert.NoError(t, err)
+
+	var tables []string
+	for _, statement := range fake.Statements() {
+		if strings.HasPrefix(statement, "INSERT INTO ") {
+			tables = append(tables, strings.Fields(statement)[2])
+		}
+		if strings.HasPrefix(statement, `INSERT INTO "order_items"`) {
+			assert.Contains(t, statement, `"notes"`)
+		}
+	}
+	assert.Equal(t, []string{`"order_daos"`, `"order_items"`, `"order_status_history"`}, tables)
+	assert.Equal(t, "COMMIT", fake.Statements()[len(fake.Statements())-1])
+
+	history, err := ds.GetStatusHistory(context.Background(), "order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []dto.OrderStatusHistoryDAO{{
+		ID:        "history-1",
+		OrderID:   "order-1",
+		ToStatus:  enum.OrderStatusAwaitingPayment,
+		ChangedBy: "customer-1",
+		ChangedAt: changedAt,
+	}}, history)
+	assert.Contains(t, fake.Statements()[len(fake.Statements())-1], `WHERE order_id = $1 ORDER BY changed_at ASC`)
+}
+
+func TestGormDataSource_ClaimDueOutboxEntries(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	db, fake := newFakeGorm(t, func(query string) fakeResult {
+		if strings.HasPrefix(query, `SELECT * FROM "order_outbox"`) {
+			return fakeResult{
+				columns: []string{"id", "status", "next_attempt_at"},
+				rows:    [][]driver.Value{{"entry-1", "pending", now}, {"entry-2", "pending", now}},
+			}
+		}
+		return fakeResult{rowsAffected: 2}
+	})
+
+	entries, err := New(db).ClaimDueOutboxEntries(context.Background(), now, 10, time.Minute)
+	assert.NoError(t, err)
+
+	assert.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, now.Add(time.Minute), entry.NextAttemptAt, "claimed entries carry their lease")
+	}
+
+	statements := fake.Statements()
+	assert.Equal(t, `SELECT * FROM "order_outbox" WHERE status = $1 AND next_attempt_at <= $2 ORDER BY next_attempt_at ASC LIMIT $3 FOR UPDATE SKIP LOCKED`, statements[0])
+	assert.Equal(t, `UPDATE "order_outbox" SET "next_attempt_at"=$1,"updated_at"=$2 WHERE id IN ($3,$4)`, statements[1])
+	assert.Equal(t, "COMMIT", statements[2])
+}
+
+func TestGormDataSource_RequeueFailedOutboxEntry(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		want         bool
+	}{
+		{name: "failed entry without a lease", rowsAffected: 1, want: true},
+		{name: "entry leased or no longer failed", rowsAffected: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeGorm(t, func(string) fakeResult {
+				return fakeResult{rowsAffected: tt.rowsAffected}
+			})
+
+			requeued, err := New(db).RequeueFailedOutboxEntry(context.Background(), dto.OutboxEntryDAO{ID: "entry-1", Status: enum.OutboxStatusPending}, time.Now())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, requeued)
+			assert.Contains(t, fake.Statements()[0], "WHERE id = $5 AND status = $6 AND next_attempt_at <= $7")
+		})
+	}
+}
+
+func TestGormDataSource_UpdatePayment(t *testing.T) {
+	db, fake := newFakeGorm(t, func(string) fakeResult { return fakeResult{rowsAffected: 1} })
+	expiresAt := time.Now().Add(time.Hour)
+
+	err := New(db).UpdatePayment(context.Background(), "order-1", "payment-1", "qr", &expiresAt)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `UPDATE "order_daos" SET "payment_id"=$1,"qr_code"=$2,"qr_code_expires_at"=$3,"updated_at"=$4 WHERE id = $5`, fake.Statements()[0])
+}
+
+func TestGormDataSource_KitchenQueueWork(t *testing.T) {
+	tests := []struct {
+		name           string
+		excludeOrderID string
+		wantWhere      string
+	}{
+		{
+			name:           "the order being estimated is left out",
+			excludeOrderID: "order-1",
+			wantWhere:      `WHERE (order_daos.status IN ($1,$2) AND order_items.quantity > 0) AND order_daos.id <> $3`,
+		},
+		{
+			name:      "an empty ID is not compared",
+			wantWhere: `WHERE order_daos.status IN ($1,$2) AND order_items.quantity > 0`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeGorm(t, func(string) fakeResult {
+				return fakeResult{
+					columns: []string{"station", "work"},
+					rows:    [][]driver.Value{{"grill", int64(40)}, {"fryer", int64(12)}},
+				}
+			})
+
+			work, err := New(db).KitchenQueueWork(context.Background(), tt.excludeOrderID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, []dto.StationWorkDAO{{Station: enum.KitchenStationGrill, Work: 40}, {Station: enum.KitchenStationFryer, Work: 12}}, work)
+			assert.Equal(t, `SELECT order_items.station, SUM(order_items.preparing_time * order_items.quantity) AS work FROM "order_items" `+
+				`JOIN order_daos ON order_daos.id = order_items.order_id `+tt.wantWhere+` GROUP BY "order_items"."station"`,
+				fake.Statements()[0])
+		})
+	}
+}
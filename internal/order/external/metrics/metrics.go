@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/interfaces"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// collectTimeout bounds the status count query
+	collectTimeout = 3 * time.Second
+	// DefaultStatusCacheTTL is how long the status counts are reused across scrapes
+	DefaultStatusCacheTTL = 30 * time.Second
+)
+
+// timeInStatusBuckets go from seconds, for payments confirmed right away, up to hours for
+// forgotten orders
+var timeInStatusBuckets = []float64{10, 30, 60, 120, 300, 600, 900, 1200, 1800, 2700, 3600, 7200}
+
+// Recorder records the order status changes as Prometheus metrics
+type Recorder struct {
+	statusChanges *prometheus.CounterVec
+	timeInStatus  *prometheus.HistogramVec
+}
+
+func NewRecorder(registerer prometheus.Registerer) *Recorder {
+	r := &Recorder{
+		statusChanges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "order_status_changes_total",
+			Help: "Order status changes, by previous and new status.",
+		}, []string{"from", "to"}),
+		timeInStatus: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "order_time_in_status_seconds",
+			Help:    "How long orders stayed in a status before moving to the next one.",
+			Buckets: timeInStatusBuckets,
+		}, []string{"status"}),
+	}
+
+	registerer.MustRegister(r.statusChanges, r.timeInStatus)
+	return r
+}
+
+var _ interfaces.OrderMetrics = (*Recorder)(nil)
+
+func (r *Recorder) ObserveStatusChange(from enum.OrderStatus, to enum.OrderStatus, timeInStatus time.Duration) {
+	r.statusChanges.WithLabelValues(from.String(), to.String()).Inc()
+	r.timeInStatus.WithLabelValues(from.String()).Observe(timeInStatus.Seconds())
+}
+
+// StatusCounter is implemented by the order use cases
+type StatusCounter interface {
+	CountByStatus(ctx context.Context) (map[enum.OrderStatus]int64, error)
+}
+
+// StatusCollector reports how many orders are in each status, read from the database so every
+// instance reports the same numbers. The counts are reused for cacheTTL, so frequent or concurrent
+// scrapes do not each run the query.
+type StatusCollector struct {
+	counter  StatusCounter
+	cacheTTL time.Duration
+	desc     *prometheus.Desc
+
+	mu        sync.Mutex
+	counts    map[enum.OrderStatus]int64
+	fetchedAt time.Time
+}
+
+func NewStatusCollector(counter StatusCounter, cacheTTL time.Duration) *StatusCollector {
+	return &StatusCollector{
+		counter:  counter,
+		cacheTTL: cacheTTL,
+		desc: prometheus.NewDesc(
+			"orders_by_status",
+			"Orders currently in each status.",
+			[]string{"status"}, nil,
+		),
+	}
+}
+
+func (c *StatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *StatusCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.countByStatus()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	// Every known status is reported, so a status with no orders reads 0 instead of disappearing
+	for name := range enum.StatusMapper {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[enum.OrderStatus(name)]), name)
+	}
+}
+
+// countByStatus returns the cached counts while they are fresh, otherwise it reads them again
+func (c *StatusCollector) countByStatus() (map[enum.OrderStatus]int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts != nil && time.Since(c.fetchedAt) < c.cacheTTL {
+		return c.counts, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	counts, err := c.counter.CountByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.counts, c.fetchedAt = counts, time.Now()
+	return counts, nil
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStatusCounter struct {
+	counts map[enum.OrderStatus]int64
+	calls  int
+}
+
+func (f *fakeStatusCounter) CountByStatus(context.Context) (map[enum.OrderStatus]int64, error) {
+	f.calls++
+	return f.counts, nil
+}
+
+func TestStatusCollector_Collect(t *testing.T) {
+	counter := &fakeStatusCounter{counts: map[enum.OrderStatus]int64{
+		enum.OrderStatusReceived:      3,
+		enum.OrderStatusInPreparation: 1,
+	}}
+	collector := NewStatusCollector(counter, time.Minute)
+
+	expected := `
+# HELP orders_by_status Orders currently in each status.
+# TYPE orders_by_status gauge
+orders_by_status{status="awaiting_payment"} 0
+orders_by_status{status="cancelled"} 0
+orders_by_status{status="completed"} 0
+orders_by_status{status="in_preparation"} 1
+orders_by_status{status="payment_rejected"} 0
+orders_by_status{status="ready"} 0
+orders_by_status{status="received"} 3
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+	assert.Equal(t, 1, counter.calls, "scrapes within the cache TTL reuse the counts")
+}
+
+func TestRecorder_ObserveStatusChange(t *testing.T) {
+	recorder := NewRecorder(prometheus.NewRegistry())
+
+	recorder.ObserveStatusChange(enum.OrderStatusReceived, enum.OrderStatusInPreparation, 90*time.Second)
+	recorder.ObserveStatusChange(enum.OrderStatusReceived, enum.OrderStatusCancelled, 30*time.Second)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(recorder.statusChanges.WithLabelValues("received", "in_preparation")))
+	assert.Equal(t, 1, testutil.CollectAndCount(recorder.timeInStatus))
+}
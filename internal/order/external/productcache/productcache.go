@@ -0,0 +1,296 @@
+package productcache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/interfaces"
+)
+
+const (
+	// DefaultTTL is how long a product is served without asking the product service again
+	DefaultTTL = time.Minute
+	// DefaultStaleTTL is how long after DefaultTTL a product is still served while it is refreshed
+	DefaultStaleTTL = 10 * time.Minute
+	// DefaultMaxAge is how old a product may be and still be served when the product service fails.
+	// Older products count as never cached, so an outage cannot keep serving an outdated price forever.
+	DefaultMaxAge = 30 * time.Minute
+	// DefaultMaxEntries bounds the products kept in memory, well above the size of a menu
+	DefaultMaxEntries = 1000
+	// refreshTimeout bounds the background refreshes, which outlive the request that started them
+	refreshTimeout = 10 * time.Second
+)
+
+// Cache is a ProductService that keeps the products returned by the product service in memory.
+// Fresh products are served from memory, stale products are served while they are refreshed in
+// the background, and when the product service fails any product cached within the max age is
+// served as a fallback so orders can still be placed. Once the cache holds max entries, the
+// oldest product is evicted to make room for a new one.
+type Cache struct {
+	next       interfaces.ProductService
+	ttl        time.Duration
+	staleTTL   time.Duration
+	maxAge     time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu         sync.RWMutex
+	entries    map[string]entry
+	refreshing map[string]bool
+
+	hits          atomic.Uint64
+	staleHits     atomic.Uint64
+	misses        atomic.Uint64
+	fallbacks     atomic.Uint64
+	refreshes     atomic.Uint64
+	refreshErrors atomic.Uint64
+	evictions     atomic.Uint64
+}
+
+type entry struct {
+	product   entity.Product
+	fetchedAt time.Time
+}
+
+// Stats are the cache counters since the service started
+type Stats struct {
+	Entries       int    `json:"entries"`
+	Hits          uint64 `json:"hits"`
+	StaleHits     uint64 `json:"stale_hits"`
+	Misses        uint64 `json:"misses"`
+	Fallbacks     uint64 `json:"fallbacks"`
+	Refreshes     uint64 `json:"refreshes"`
+	RefreshErrors uint64 `json:"refresh_errors"`
+	Evictions     uint64 `json:"evictions"`
+}
+
+// New creates the cache. The max age is raised to ttl+staleTTL when shorter, as products served
+// while they are refreshed must also be good enough as a fallback.
+func New(next interfaces.ProductService, ttl time.Duration, staleTTL time.Duration, maxAge time.Duration, maxEntries int) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if staleTTL < 0 {
+		staleTTL = 0
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	if maxAge < ttl+staleTTL {
+		maxAge = ttl + staleTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	return &Cache{
+		next:       next,
+		ttl:        ttl,
+		staleTTL:   staleTTL,
+		maxAge:     maxAge,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]entry),
+		refreshing: make(map[string]bool),
+	}
+}
+
+var _ interfaces.ProductService = (*Cache)(nil)
+
+// FindByIDs returns the products in the order of productIDs, once per distinct ID. Products the
+// product service does not know are left out, as the product service itself does.
+func (c *Cache) FindByIDs(ctx context.Context, productIDs []string) ([]entity.Product, error) {
+	ids := distinct(productIDs)
+	now := c.now()
+
+	found := make(map[string]entity.Product, len(ids))
+	var missing, stale []string
+
+	c.mu.RLock()
+	for _, id := range ids {
+		cached, ok := c.entries[id]
+		switch {
+		case !ok:
+			missing = append(missing, id)
+		case now.Sub(cached.fetchedAt) < c.ttl:
+			found[id] = cached.product
+		case now.Sub(cached.fetchedAt) < c.ttl+c.staleTTL:
+			found[id] = cached.product
+			stale = append(stale, id)
+		default:
+			missing = append(missing, id)
+		}
+	}
+	c.mu.RUnlock()
+
+	c.hits.Add(uint64(len(found) - len(stale)))
+	c.staleHits.Add(uint64(len(stale)))
+	c.misses.Add(uint64(len(missing)))
+
+	if len(stale) > 0 {
+		c.refreshInBackground(ctx, stale)
+	}
+
+	if len(missing) > 0 {
+		products, err := c.next.FindByIDs(ctx, missing)
+		if err != nil {
+			if !c.fallback(missing, found, now) {
+				return nil, err
+			}
+		} else {
+			c.store(products, now)
+			for _, product := range products {
+				found[product.Id] = product
+			}
+		}
+	}
+
+	products := make([]entity.Product, 0, len(found))
+	for _, id := range ids {
+		if product, ok := found[id]; ok {
+			products = append(products, product)
+		}
+	}
+
+	return products, nil
+}
+
+// Invalidate drops the given products so the next lookup asks the product service
+func (c *Cache) Invalidate(productIDs ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range productIDs {
+		delete(c.entries, id)
+	}
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.RLock()
+	entries := len(c.entries)
+	c.mu.RUnlock()
+
+	return Stats{
+		Entries:       entries,
+		Hits:          c.hits.Load(),
+		StaleHits:     c.staleHits.Load(),
+		Misses:        c.misses.Load(),
+		Fallbacks:     c.fallbacks.Load(),
+		Refreshes:     c.refreshes.Load(),
+		RefreshErrors: c.refreshErrors.Load(),
+		Evictions:     c.evictions.Load(),
+	}
+}
+
+// fallback fills found with the expired products still cached for ids. It reports false when any
+// of them was never cached or is older than the max age, in which case the product service error
+// must be returned.
+func (c *Cache) fallback(ids []string, found map[string]entity.Product, now time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, id := range ids {
+		cached, ok := c.entries[id]
+		if !ok || now.Sub(cached.fetchedAt) >= c.maxAge {
+			return false
+		}
+	}
+
+	for _, id := range ids {
+		found[id] = c.entries[id].product
+	}
+	c.fallbacks.Add(uint64(len(ids)))
+	return true
+}
+
+// refreshInBackground refreshes the stale products not already being refreshed
+func (c *Cache) refreshInBackground(ctx context.Context, ids []string) {
+	c.mu.Lock()
+	toRefresh := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !c.refreshing[id] {
+			c.refreshing[id] = true
+			toRefresh = append(toRefresh, id)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(toRefresh) == 0 {
+		return
+	}
+
+	refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+	go func() {
+		defer cancel()
+		c.refresh(refreshCtx, toRefresh)
+	}()
+}
+
+func (c *Cache) refresh(ctx context.Context, ids []string) {
+	defer func() {
+		c.mu.Lock()
+		for _, id := range ids {
+			delete(c.refreshing, id)
+		}
+		c.mu.Unlock()
+	}()
+
+	c.refreshes.Add(1)
+	products, err := c.next.FindByIDs(ctx, ids)
+	if err != nil {
+		// The stale products keep being served and the next lookup tries again
+		c.refreshErrors.Add(1)
+		return
+	}
+
+	c.store(products, c.now())
+}
+
+func (c *Cache) store(products []entity.Product, fetchedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, product := range products {
+		if _, ok := c.entries[product.Id]; !ok && len(c.entries) >= c.maxEntries {
+			c.evict(fetchedAt)
+		}
+		c.entries[product.Id] = entry{product: product, fetchedAt: fetchedAt}
+	}
+}
+
+// evict drops the products past the max age, or the oldest one when none is, to make room for a
+// new product. It must be called with the lock held.
+func (c *Cache) evict(now time.Time) {
+	oldestID := ""
+	var oldest time.Time
+	for id, cached := range c.entries {
+		if now.Sub(cached.fetchedAt) >= c.maxAge {
+			delete(c.entries, id)
+			c.evictions.Add(1)
+			continue
+		}
+		if oldestID == "" || cached.fetchedAt.Before(oldest) {
+			oldestID, oldest = id, cached.fetchedAt
+		}
+	}
+
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, oldestID)
+		c.evictions.Add(1)
+	}
+}
+
+func distinct(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
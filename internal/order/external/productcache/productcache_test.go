@@ -0,0 +1,173 @@
+package productcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProductService struct {
+	mu       sync.Mutex
+	products map[string]entity.Product
+	err      error
+	calls    [][]string
+}
+
+func (f *fakeProductService) FindByIDs(_ context.Context, productIDs []string) ([]entity.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, productIDs)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var products []entity.Product
+	for _, id := range productIDs {
+		if product, ok := f.products[id]; ok {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (f *fakeProductService) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.calls)
+}
+
+func newTestCache(next *fakeProductService) (*Cache, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := New(next, time.Minute, 5*time.Minute, 2*time.Hour, 3)
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func TestCache_FindByIDs(t *testing.T) {
+	next := &fakeProductService{products: map[string]entity.Product{
+		"burger": {Id: "burger", Name: "Burger"},
+		"fries":  {Id: "fries", Name: "Fries"},
+	}}
+	cache, now := newTestCache(next)
+	ctx := context.Background()
+
+	products, err := cache.FindByIDs(ctx, []string{"burger", "fries", "burger"})
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.Product{next.products["burger"], next.products["fries"]}, products)
+
+	*now = now.Add(30 * time.Second)
+	_, err = cache.FindByIDs(ctx, []string{"fries"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, next.callCount(), "fresh products are served from memory")
+
+	cache.Invalidate("fries")
+	_, err = cache.FindByIDs(ctx, []string{"fries"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fries"}, next.calls[1], "invalidated products are fetched again")
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(3), stats.Misses)
+	assert.Equal(t, 2, stats.Entries)
+}
+
+func TestCache_ServesStaleWhileRevalidating(t *testing.T) {
+	next := &fakeProductService{products: map[string]entity.Product{"burger": {Id: "burger", Name: "Burger"}}}
+	cache, now := newTestCache(next)
+	ctx := context.Background()
+
+	_, _ = cache.FindByIDs(ctx, []string{"burger"})
+	next.mu.Lock()
+	next.products["burger"] = entity.Product{Id: "burger", Name: "Double Burger"}
+	next.mu.Unlock()
+
+	*now = now.Add(2 * time.Minute)
+	products, err := cache.FindByIDs(ctx, []string{"burger"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Burger", products[0].Name, "stale product is served right away")
+
+	assert.Eventually(t, func() bool {
+		products, _ := cache.FindByIDs(ctx, []string{"burger"})
+		return products[0].Name == "Double Burger"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(1), cache.Stats().Refreshes)
+}
+
+func TestCache_FallsBackWhenProductServiceFails(t *testing.T) {
+	next := &fakeProductService{products: map[string]entity.Product{"burger": {Id: "burger", Name: "Burger"}}}
+	cache, now := newTestCache(next)
+	ctx := context.Background()
+
+	_, _ = cache.FindByIDs(ctx, []string{"burger"})
+	next.err = errors.New("product service unavailable")
+	*now = now.Add(time.Hour)
+
+	products, err := cache.FindByIDs(ctx, []string{"burger"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Burger", products[0].Name)
+	assert.Equal(t, uint64(1), cache.Stats().Fallbacks)
+
+	_, err = cache.FindByIDs(ctx, []string{"burger", "fries"})
+	assert.EqualError(t, err, "product service unavailable", "products never cached cannot fall back")
+
+	*now = now.Add(time.Hour)
+	_, err = cache.FindByIDs(ctx, []string{"burger"})
+	assert.EqualError(t, err, "product service unavailable", "products past the max age cannot fall back")
+}
+
+func TestCache_EvictsWhenFull(t *testing.T) {
+	tests := []struct {
+		name        string
+		elapsed     time.Duration
+		wantCached  []string
+		wantEvicted uint64
+	}{
+		{
+			name:        "the oldest product makes room",
+			elapsed:     time.Minute,
+			wantCached:  []string{"fries", "soda", "shake"},
+			wantEvicted: 1,
+		},
+		{
+			name:        "products past the max age are dropped first",
+			elapsed:     3 * time.Hour,
+			wantCached:  []string{"shake"},
+			wantEvicted: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &fakeProductService{products: map[string]entity.Product{
+				"burger": {Id: "burger"},
+				"fries":  {Id: "fries"},
+				"soda":   {Id: "soda"},
+				"shake":  {Id: "shake"},
+			}}
+			cache, now := newTestCache(next)
+			ctx := context.Background()
+
+			for _, id := range []string{"burger", "fries", "soda"} {
+				_, _ = cache.FindByIDs(ctx, []string{id})
+				*now = now.Add(time.Second)
+			}
+			*now = now.Add(tt.elapsed)
+			_, _ = cache.FindByIDs(ctx, []string{"shake"})
+
+			cached := make([]string, 0, len(cache.entries))
+			for _, id := range []string{"burger", "fries", "soda", "shake"} {
+				if _, ok := cache.entries[id]; ok {
+					cached = append(cached, id)
+				}
+			}
+			assert.Equal(t, tt.wantCached, cached)
+			assert.Equal(t, tt.wantEvicted, cache.Stats().Evictions)
+		})
+	}
+}
@@ -2,11 +2,16 @@ package gateway
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/dto"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/external/datasource"
 	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Gateway struct {
@@ -19,44 +24,135 @@ func Build(datasource datasource.DataSource) *Gateway {
 	}
 }
 
-func (g *Gateway) Create(ctx context.Context, order entity.Order) (entity.Order, error) {
+func (g *Gateway) Create(ctx context.Context, order entity.Order, history entity.OrderStatusHistory, outbox []entity.OutboxEntry) (_ entity.Order, err error) {
+	ctx, span := tracing.Start(ctx, "Gateway.Create", attribute.String("order.id", order.ID), attribute.Int("outbox.entries", len(outbox)))
+	defer func() { tracing.End(span, err) }()
+
 	orderDAO := dto.ToOrderDAO(order)
-	created, err := g.Datasource.Create(ctx, orderDAO)
+	created, err := g.Datasource.Create(ctx, orderDAO, dto.ToOrderStatusHistoryDAO(history), dto.ToOutboxEntryDAOList(outbox))
 	if err != nil {
-		return entity.Order{}, &apperror.InternalError{Msg: err.Error()}
+		return entity.Order{}, apperror.FromStorage(err, "order not found", "")
 	}
 	return dto.FromOrderDAO(created), nil
 }
 
-func (g *Gateway) GetAll(ctx context.Context) ([]entity.Order, error) {
-	ordersDAO, err := g.Datasource.GetAll(ctx)
+func (g *Gateway) GetAll(ctx context.Context, filter dto.OrderFilterDTO) ([]entity.Order, int64, error) {
+	ordersDAO, total, err := g.Datasource.GetAll(ctx, filter)
 	if err != nil {
-		return nil, &apperror.InternalError{Msg: err.Error()}
+		return nil, 0, apperror.FromStorage(err, "order not found", "")
 	}
-	return dto.EntityListFromDAOList(ordersDAO), nil
+	return dto.EntityListFromDAOList(ordersDAO), total, nil
 }
 
 func (g *Gateway) GetPanel(ctx context.Context) ([]entity.Order, error) {
 	ordersDAO, err := g.Datasource.GetPanel(ctx)
 	if err != nil {
-		return nil, &apperror.InternalError{Msg: err.Error()}
+		return nil, apperror.FromStorage(err, "order not found", "")
 	}
 	return dto.EntityListFromDAOList(ordersDAO), nil
 }
 
+// KitchenQueueWork returns the work queued on each kitchen station by the orders other than
+// excludeOrderID, with unknown stations counted on the assembly station
+func (g *Gateway) KitchenQueueWork(ctx context.Context, excludeOrderID string) (entity.StationWork, error) {
+	workDAO, err := g.Datasource.KitchenQueueWork(ctx, excludeOrderID)
+	if err != nil {
+		return nil, apperror.FromStorage(err, "order not found", "")
+	}
+
+	work := entity.StationWork{}
+	for _, station := range workDAO {
+		work[enum.ParseKitchenStation(station.Station.String())] += uint(max(station.Work, 0))
+	}
+	return work, nil
+}
+
 func (g *Gateway) FindByID(ctx context.Context, id string) (entity.Order, error) {
 	orderDAO, err := g.Datasource.FindByID(ctx, id)
 	if err != nil {
-		return entity.Order{}, err
+		return entity.Order{}, apperror.FromStorage(err, "order not found", "")
 	}
 	return dto.FromOrderDAO(orderDAO), nil
 }
 
-func (g *Gateway) Update(ctx context.Context, order entity.Order) (entity.Order, error) {
+func (g *Gateway) Update(ctx context.Context, order entity.Order, history entity.OrderStatusHistory) (entity.Order, error) {
 	orderDAO := dto.ToOrderDAO(order)
-	updated, err := g.Datasource.Update(ctx, orderDAO)
+	historyDAO := dto.ToOrderStatusHistoryDAO(history)
+	updated, err := g.Datasource.Update(ctx, orderDAO, historyDAO)
+	if errors.Is(err, datasource.ErrStatusChanged) {
+		return entity.Order{}, &apperror.ConflictError{Msg: "order status changed, reload the order and try again"}
+	}
 	if err != nil {
-		return entity.Order{}, &apperror.InternalError{Msg: err.Error()}
+		return entity.Order{}, apperror.FromStorage(err, "order not found", "")
 	}
 	return dto.FromOrderDAO(updated), nil
 }
+
+func (g *Gateway) SavePayment(ctx context.Context, orderID string, payment entity.Payment) error {
+	if err := g.Datasource.UpdatePayment(ctx, orderID, payment.ID, payment.QRCode, payment.ExpiresAt); err != nil {
+		return apperror.FromStorage(err, "order not found", "")
+	}
+	return nil
+}
+
+func (g *Gateway) GetStatusHistory(ctx context.Context, orderID string) ([]entity.OrderStatusHistory, error) {
+	historyDAO, err := g.Datasource.GetStatusHistory(ctx, orderID)
+	if err != nil {
+		return nil, apperror.FromStorage(err, "order not found", "")
+	}
+	return dto.HistoryEntityListFromDAOList(historyDAO), nil
+}
+
+func (g *Gateway) ClaimDueOutboxEntries(ctx context.Context, limit int) ([]entity.OutboxEntry, error) {
+	entriesDAO, err := g.Datasource.ClaimDueOutboxEntries(ctx, time.Now(), limit, entity.OutboxLease)
+	if err != nil {
+		return nil, apperror.FromStorage(err, "outbox entry not found", "")
+	}
+	return dto.OutboxEntityListFromDAOList(entriesDAO), nil
+}
+
+func (g *Gateway) UpdateOutboxEntry(ctx context.Context, entry entity.OutboxEntry) error {
+	if err := g.Datasource.UpdateOutboxEntry(ctx, dto.ToOutboxEntryDAO(entry)); err != nil {
+		return apperror.FromStorage(err, "outbox entry not found", "")
+	}
+	return nil
+}
+
+// RequeueFailedOutboxEntry saves the requeued entry and reports false when it is no longer failed or
+// a dispatcher is delivering it
+func (g *Gateway) RequeueFailedOutboxEntry(ctx context.Context, entry entity.OutboxEntry, now time.Time) (bool, error) {
+	requeued, err := g.Datasource.RequeueFailedOutboxEntry(ctx, dto.ToOutboxEntryDAO(entry), now)
+	if err != nil {
+		return false, apperror.FromStorage(err, "outbox entry not found", "")
+	}
+	return requeued, nil
+}
+
+func (g *Gateway) FindOutboxEntryByID(ctx context.Context, id string) (entity.OutboxEntry, error) {
+	entryDAO, err := g.Datasource.FindOutboxEntryByID(ctx, id)
+	if err != nil {
+		return entity.OutboxEntry{}, apperror.FromStorage(err, "outbox entry not found", "")
+	}
+	return dto.FromOutboxEntryDAO(entryDAO), nil
+}
+
+func (g *Gateway) GetStuckOutboxEntries(ctx context.Context) ([]entity.OutboxEntry, error) {
+	entriesDAO, err := g.Datasource.GetStuckOutboxEntries(ctx)
+	if err != nil {
+		return nil, apperror.FromStorage(err, "outbox entry not found", "")
+	}
+	return dto.OutboxEntityListFromDAOList(entriesDAO), nil
+}
+
+func (g *Gateway) CountByStatus(ctx context.Context) (map[enum.OrderStatus]int64, error) {
+	countsDAO, err := g.Datasource.CountByStatus(ctx)
+	if err != nil {
+		return nil, apperror.FromStorage(err, "order not found", "")
+	}
+
+	counts := make(map[enum.OrderStatus]int64, len(countsDAO))
+	for _, count := range countsDAO {
+		counts[count.Status] = count.Count
+	}
+	return counts, nil
+}
@@ -24,15 +24,16 @@ func (a *OrderServiceGateway) FindByID(ctx context.Context, orderID string) (ent
 	}
 
 	return entity.Order{
-		Entity:        order.Entity,
-		CustomerID:    order.Entity.ID,
-		Status:        order.Status,
-		Price:         order.Price,
-		PreparingTime: order.PreparingTime,
+		Entity:             order.Entity,
+		CustomerID:         order.Entity.ID,
+		Status:             order.Status,
+		Price:              order.Price,
+		PreparingTime:      order.PreparingTime,
+		CancellationReason: order.CancellationReason,
 	}, nil
 }
 
-func (a *OrderServiceGateway) Update(ctx context.Context, order entity.Order) (entity.Order, error) {
+func (a *OrderServiceGateway) Update(ctx context.Context, order entity.Order, changedBy string) (entity.Order, error) {
 	currentOrder, err := a.orderUseCase.FindByID(ctx, order.Entity.ID)
 	if err != nil {
 		return entity.Order{}, err
@@ -40,16 +41,17 @@ func (a *OrderServiceGateway) Update(ctx context.Context, order entity.Order) (e
 
 	currentOrder.Status = order.Status
 
-	updatedOrder, updateErr := a.orderUseCase.Update(ctx, currentOrder)
+	updatedOrder, updateErr := a.orderUseCase.Update(ctx, currentOrder, changedBy)
 	if updateErr != nil {
 		return entity.Order{}, updateErr
 	}
 
 	return entity.Order{
-		Entity:        updatedOrder.Entity,
-		CustomerID:    updatedOrder.CustomerID,
-		Status:        updatedOrder.Status,
-		Price:         updatedOrder.Price,
-		PreparingTime: updatedOrder.PreparingTime,
+		Entity:             updatedOrder.Entity,
+		CustomerID:         updatedOrder.CustomerID,
+		Status:             updatedOrder.Status,
+		Price:              updatedOrder.Price,
+		PreparingTime:      updatedOrder.PreparingTime,
+		CancellationReason: updatedOrder.CancellationReason,
 	}, nil
 }
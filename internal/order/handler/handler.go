@@ -1,23 +1,44 @@
 package handler
 
 import (
-	"context"
+	"io"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/controller"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/dto"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/external/broadcaster"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/external/productcache"
 	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/helper"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/tracing"
 	"github.com/gin-gonic/gin"
 )
 
+// panelHeartbeatInterval keeps idle panel streams alive through proxies and load balancers
+const panelHeartbeatInterval = 15 * time.Second
+
 type Handler struct {
-	controller *controller.Controller
+	controller       *controller.Controller
+	panelBroadcaster *broadcaster.Broadcaster
+	productCache     *productcache.Cache
+	logger           *slog.Logger
 }
 
-func New(controller *controller.Controller) *Handler {
-	return &Handler{controller: controller}
+func New(
+	controller *controller.Controller,
+	panelBroadcaster *broadcaster.Broadcaster,
+	productCache *productcache.Cache,
+	logger *slog.Logger,
+) *Handler {
+	return &Handler{
+		controller:       controller,
+		panelBroadcaster: panelBroadcaster,
+		productCache:     productCache,
+		logger:           logger,
+	}
 }
 
 // Create Order godoc
@@ -28,7 +49,7 @@ func New(controller *controller.Controller) *Handler {
 // @Accept       json
 // @Produce      json
 // @Param        request body dto.CreateOrderDTO true "Order to create. Note that the customer_id is automatically set from the authenticated user."
-// @Success      200  {object}  map[string]any
+// @Success      200  {object}  dto.CreateOrderResponseDTO
 // @Failure      400  {object}  errors.ErrorDTO
 // @Failure      401  {object}  errors.ErrorDTO
 // @Router       /order/ [post]
@@ -36,6 +57,7 @@ func (h *Handler) Create(c *gin.Context) {
 	var orderDTO dto.CreateOrderDTO
 	if err := c.ShouldBindJSON(&orderDTO); err != nil {
 		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeInvalidRequest,
 			Message:      "invalid request body",
 			MessageError: err.Error(),
 		})
@@ -43,6 +65,7 @@ func (h *Handler) Create(c *gin.Context) {
 	}
 	if err := orderDTO.Validate(); err != nil {
 		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeValidationFailed,
 			Message:      "validation failed",
 			MessageError: err.Error(),
 		})
@@ -51,6 +74,7 @@ func (h *Handler) Create(c *gin.Context) {
 	customerIDRaw, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, apperror.ErrorDTO{
+			Code:         apperror.CodeUnauthorized,
 			Message:      "unauthorized",
 			MessageError: "user id not found in context",
 		})
@@ -59,20 +83,43 @@ func (h *Handler) Create(c *gin.Context) {
 	customerID := customerIDRaw.(string)
 	orderDTO.CustomerID = customerID
 
-	qrCode, err := h.controller.Create(context.Background(), orderDTO)
+	ctx, span := tracing.Start(c.Request.Context(), "Handler.Create")
+	created, err := h.controller.Create(ctx, orderDTO)
+	tracing.End(span, err)
 	if err != nil {
 		helper.HandleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"qr_code": qrCode,
-		"message": "Order created successfully",
-	})
+	c.JSON(http.StatusOK, created)
+}
+
+// GetPayment godoc
+// @Summary      Get order payment
+// @Description  Get the payment id and QR code of an order. pending is true while the payment is still being created
+// @Tags         Order Domain
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  dto.PaymentDTO
+// @Failure      400  {object}  errors.ErrorDTO
+// @Failure      401  {object}  errors.ErrorDTO
+// @Failure      404  {object}  errors.ErrorDTO
+// @Failure      500  {object}  errors.ErrorDTO
+// @Router       /admin/orders/{id}/payment [get]
+func (h *Handler) GetPayment(c *gin.Context) {
+	payment, err := h.controller.GetPayment(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, payment)
 }
 
 // Update Order godoc
 // @Summary      Update Order
-// @Description  Update an existing order status
+// @Description  Update an existing order status. Only transitions allowed by the order status flow are accepted
+// @Description  (awaiting_payment → received → in_preparation → ready → completed). Use the cancel endpoint to cancel
+// @Description  Invalid or disallowed statuses are rejected with 400, a status changed concurrently with 409
 // @Tags         Order Domain
 // @Security     BearerAuth
 // @Accept       json
@@ -83,24 +130,26 @@ func (h *Handler) Create(c *gin.Context) {
 // @Failure      400  {object}  errors.ErrorDTO
 // @Failure      401  {object}  errors.ErrorDTO
 // @Failure      404  {object}  errors.ErrorDTO
+// @Failure      409  {object}  errors.ErrorDTO
 // @Router       /order/{id} [put]
 func (h *Handler) Update(c *gin.Context) {
 	id := c.Param("id")
 	var orderUpdate dto.UpdateOrderDTO
 	if err := c.ShouldBindJSON(&orderUpdate); err != nil {
 		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeInvalidRequest,
 			Message:      "Invalid request body",
 			MessageError: err.Error(),
 		})
 		return
 	}
-	orderDAO, err := h.controller.FindByID(context.Background(), id)
+	orderDAO, err := h.controller.FindByID(c.Request.Context(), id)
 	if err != nil {
 		helper.HandleError(c, err)
 		return
 	}
 	orderDAO.Status = enum.OrderStatus(orderUpdate.Status)
-	_, err = h.controller.Update(context.Background(), orderDAO)
+	_, err = h.controller.Update(c.Request.Context(), orderDAO, c.GetString("user_id"))
 	if err != nil {
 		helper.HandleError(c, err)
 		return
@@ -108,29 +157,151 @@ func (h *Handler) Update(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// Cancel Order godoc
+// @Summary      Cancel Order
+// @Description  Cancel an order that has not been made ready yet, recording the reason and refunding its payment, if one was created
+// @Tags         Order Domain
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Order ID"
+// @Param        request body dto.CancelOrderDTO true "Cancellation reason"
+// @Success      200  {object}  dto.OrderDAO
+// @Failure      400  {object}  errors.ErrorDTO
+// @Failure      401  {object}  errors.ErrorDTO
+// @Failure      404  {object}  errors.ErrorDTO
+// @Failure      409  {object}  errors.ErrorDTO
+// @Failure      500  {object}  errors.ErrorDTO
+// @Failure      503  {object}  errors.ErrorDTO
+// @Router       /admin/orders/{id}/cancel [post]
+func (h *Handler) Cancel(c *gin.Context) {
+	id := c.Param("id")
+	var cancelDTO dto.CancelOrderDTO
+	if err := c.ShouldBindJSON(&cancelDTO); err != nil {
+		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeInvalidRequest,
+			Message:      "Invalid request body",
+			MessageError: err.Error(),
+		})
+		return
+	}
+	cancelled, err := h.controller.Cancel(c.Request.Context(), id, cancelDTO, c.GetString("user_id"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cancelled)
+}
+
+// PaymentWebhook godoc
+// @Summary      Payment notification webhook
+// @Description  Receives approved or rejected payment notifications and moves the order out of awaiting_payment.
+// @Description  Duplicate or late notifications are acknowledged with applied=false. Requires the payment service
+// @Description  API key headers or an X-Signature header with the hex HMAC-SHA256 of "<timestamp>.<body>", where the
+// @Description  timestamp is the unix time in seconds sent in X-Signature-Timestamp, at most 5 minutes away from now.
+// @Description  Bodies over 64 KiB are rejected with 413.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.PaymentNotificationDTO true "Payment notification"
+// @Success      200  {object}  dto.PaymentNotificationResultDTO
+// @Failure      400  {object}  errors.ErrorDTO
+// @Failure      401  {object}  errors.ErrorDTO
+// @Failure      413  {object}  errors.ErrorDTO
+// @Failure      500  {object}  errors.ErrorDTO
+// @Router       /webhooks/payment [post]
+func (h *Handler) PaymentWebhook(c *gin.Context) {
+	var notification dto.PaymentNotificationDTO
+	if err := c.ShouldBindJSON(&notification); err != nil {
+		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeInvalidRequest,
+			Message:      "Invalid request body",
+			MessageError: err.Error(),
+		})
+		return
+	}
+	result, err := h.controller.ProcessPaymentNotification(c.Request.Context(), notification)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	h.logger.InfoContext(c.Request.Context(), "payment notification processed",
+		slog.String("order_id", notification.OrderID),
+		slog.String("payment_status", notification.Status),
+		slog.Bool("applied", result.Applied))
+	c.JSON(http.StatusOK, result)
+}
+
+// GetHistory godoc
+// @Summary      Get order status history
+// @Description  Retrieve every status change of an order, oldest first, with who performed it
+// @Tags         Order Domain
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  dto.OrderStatusHistoryResponseDTO
+// @Failure      400  {object}  errors.ErrorDTO
+// @Failure      401  {object}  errors.ErrorDTO
+// @Failure      500  {object}  errors.ErrorDTO
+// @Router       /admin/orders/{id}/history [get]
+func (h *Handler) GetHistory(c *gin.Context) {
+	id := c.Param("id")
+	history, err := h.controller.GetStatusHistory(c.Request.Context(), id)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
 // GetAll godoc
 // @Summary      Get all orders
-// @Description  Retrieve a list of all orders, optionally filtered by ID
+// @Description  Retrieve a page of orders, optionally filtered by ID, status, customer, creation date and price
 // @Tags         Order Domain
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
-// @Param        id   query     string  false  "Optional order ID filter"
+// @Param        id            query     string    false  "Optional order ID filter, other filters are ignored when set"
+// @Param        page          query     int       false  "Page number, starting at 1"  default(1)
+// @Param        page_size     query     int       false  "Orders per page, at most 100"  default(20)
+// @Param        status        query     []string  false  "Order status, may be repeated"  collectionFormat(multi)
+// @Param        customer_id   query     string    false  "Customer ID"
+// @Param        created_from  query     string    false  "Created at or after (RFC3339)"
+// @Param        created_to    query     string    false  "Created at or before (RFC3339)"
+// @Param        min_price     query     number    false  "Minimum total price"
+// @Param        max_price     query     number    false  "Maximum total price"
+// @Param        sort_by       query     string    false  "Sort field"  Enums(created_at, updated_at, price, status, preparing_time)  default(created_at)
+// @Param        sort_order    query     string    false  "Sort direction"  Enums(asc, desc)  default(desc)
 // @Success      200  {object}  dto.OrderResponseListDTO
 // @Failure      400  {object}  errors.ErrorDTO
 // @Failure      401  {object}  errors.ErrorDTO
 // @Failure      500  {object}  errors.ErrorDTO
-// @Router       /order/ [get]
+// @Router       /admin/orders [get]
 func (h *Handler) GetAll(c *gin.Context) {
-	id := c.Query("id")
-	orders, err := h.controller.GetAll(context.Background(), id)
+	var filter dto.OrderFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeInvalidRequest,
+			Message:      "invalid query parameters",
+			MessageError: err.Error(),
+		})
+		return
+	}
+	if err := filter.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, apperror.ErrorDTO{
+			Code:         apperror.CodeValidationFailed,
+			Message:      "validation failed",
+			MessageError: err.Error(),
+		})
+		return
+	}
+	orders, err := h.controller.GetAll(c.Request.Context(), filter)
 	if err != nil {
 		helper.HandleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, dto.OrderResponseListDTO{
-		Orders: orders,
-	})
+	c.JSON(http.StatusOK, orders)
 }
 
 // GetPanel Get Order Panel godoc
@@ -145,19 +316,137 @@ func (h *Handler) GetAll(c *gin.Context) {
 // @Failure      401  {object}  errors.ErrorDTO
 // @Router       /order/panel [get]
 func (h *Handler) GetPanel(c *gin.Context) {
-	orders, err := h.controller.GetPanel(context.Background())
+	orders, err := h.controller.GetPanel(c.Request.Context())
 	if err != nil {
 		helper.HandleError(c, err)
 		return
 	}
-	panel := dto.OrderPanelDTO{Orders: []dto.OrderPanelItemDTO{}}
-	for _, order := range orders {
-		panel.Orders = append(panel.Orders, dto.OrderPanelItemDTO{
-			OrderNumber:   order.Entity.ID[len(order.Entity.ID)-4:],
-			Status:        string(order.Status),
-			PreparingTime: order.PreparingTime,
-			CreatedAt:     order.CreatedAt,
-		})
+	c.JSON(http.StatusOK, dto.ToOrderPanelDTO(orders))
+}
+
+// StreamPanel godoc
+// @Summary      Stream Order Panel
+// @Description  Server-Sent Events stream of the order panel. The first event is a full snapshot,
+// @Description  followed by an order_changed event whenever an order is created or changes status.
+// @Description  When the client falls behind the stream is closed and it should reconnect.
+// @Tags         Order Domain
+// @Security     BearerAuth
+// @Produce      text/event-stream
+// @Success      200  {object}  dto.OrderPanelEventDTO
+// @Failure      401  {object}  errors.ErrorDTO
+// @Failure      500  {object}  errors.ErrorDTO
+// @Router       /admin/orders/panel/stream [get]
+func (h *Handler) StreamPanel(c *gin.Context) {
+	// Subscribe before loading the snapshot so no change between both steps is lost
+	events, unsubscribe := h.panelBroadcaster.Subscribe()
+	defer unsubscribe()
+
+	orders, err := h.controller.GetPanel(c.Request.Context())
+	if err != nil {
+		helper.HandleError(c, err)
+		return
 	}
-	c.JSON(http.StatusOK, panel)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent(dto.PanelEventSnapshot, dto.OrderPanelEventDTO{
+		Type:   dto.PanelEventSnapshot,
+		Orders: dto.ToOrderPanelDTO(orders).Orders,
+	})
+	c.Writer.Flush()
+
+	h.logger.InfoContext(c.Request.Context(), "panel stream opened",
+		slog.Int("subscribers", h.panelBroadcaster.SubscriberCount()))
+	defer h.logger.InfoContext(c.Request.Context(), "panel stream closed")
+
+	heartbeat := time.NewTicker(panelHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			_, _ = io.WriteString(w, ": heartbeat\n\n")
+			return true
+		}
+	})
+}
+
+// GetStuckOutbox godoc
+// @Summary      List stuck outbox entries
+// @Description  List downstream calls of created orders that failed at least once or ran out of attempts
+// @Tags         Order Domain
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  dto.OutboxEntryListDTO
+// @Failure      401  {object}  errors.ErrorDTO
+// @Failure      500  {object}  errors.ErrorDTO
+// @Router       /admin/outbox [get]
+func (h *Handler) GetStuckOutbox(c *gin.Context) {
+	entries, err := h.controller.GetStuckOutboxEntries(c.Request.Context())
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// RetryOutbox godoc
+// @Summary      Retry outbox entry
+// @Description  Requeue a failed outbox entry for immediate delivery with a fresh attempt budget. Pending entries and entries still leased by a dispatcher are rejected with 409.
+// @Tags         Order Domain
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Outbox entry ID"
+// @Success      200  {object}  dto.OutboxEntryDAO
+// @Failure      400  {object}  errors.ErrorDTO
+// @Failure      401  {object}  errors.ErrorDTO
+// @Failure      404  {object}  errors.ErrorDTO
+// @Failure      409  {object}  errors.ErrorDTO
+// @Failure      500  {object}  errors.ErrorDTO
+// @Router       /admin/outbox/{id}/retry [post]
+func (h *Handler) RetryOutbox(c *gin.Context) {
+	entry, err := h.controller.RetryOutboxEntry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetProductCacheStats godoc
+// @Summary      Product cache stats
+// @Description  Counters of the in-memory product catalog cache used when creating orders
+// @Tags         Order Domain
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  productcache.Stats
+// @Failure      401  {object}  errors.ErrorDTO
+// @Router       /admin/products/cache [get]
+func (h *Handler) GetProductCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.productCache.Stats())
+}
+
+// InvalidateProductCache godoc
+// @Summary      Invalidate a cached product
+// @Description  Drop a product from the product catalog cache so the next order reads it from the product service
+// @Tags         Order Domain
+// @Security     BearerAuth
+// @Param        id path string true "Product ID"
+// @Success      204
+// @Failure      401  {object}  errors.ErrorDTO
+// @Router       /admin/products/cache/{id} [delete]
+func (h *Handler) InvalidateProductCache(c *gin.Context) {
+	h.productCache.Invalidate(c.Param("id"))
+	h.logger.InfoContext(c.Request.Context(), "product cache invalidated", slog.String("product_id", c.Param("id")))
+	c.Status(http.StatusNoContent)
 }
@@ -2,8 +2,10 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
 )
 
 type ProductService interface {
@@ -15,5 +17,16 @@ type ProductOrderService interface {
 }
 
 type PaymentService interface {
-	CreateByOrderID(ctx context.Context, orderID string) error
+	CreateByOrderID(ctx context.Context, orderID string) (entity.Payment, error)
+	RefundByOrderID(ctx context.Context, orderID string, reason string) error
+}
+
+// OrderChangeNotifier is told about every order that was created or changed status
+type OrderChangeNotifier interface {
+	NotifyOrderChanged(order entity.Order)
+}
+
+// OrderMetrics records how long orders stay in each status
+type OrderMetrics interface {
+	ObserveStatusChange(from enum.OrderStatus, to enum.OrderStatus, timeInStatus time.Duration)
 }
@@ -15,6 +15,18 @@ func (p *Presenter) FromEntityToDAO(order entity.Order) dto.OrderDAO {
 	return dto.ToOrderDAO(order)
 }
 
+func (p *Presenter) FromEntityToCreateResponse(order entity.Order) dto.CreateOrderResponseDTO {
+	message := "Order created successfully"
+	payment := dto.ToPaymentDTO(dto.ToOrderDAO(order))
+	if payment.Pending {
+		message = "Order created successfully, payment is still being created"
+	}
+	return dto.CreateOrderResponseDTO{
+		Message:    message,
+		PaymentDTO: payment,
+	}
+}
+
 func (p *Presenter) FromEntityListToDAOList(orders []entity.Order) []dto.OrderDAO {
 	var ordersDAO []dto.OrderDAO
 	for _, order := range orders {
@@ -22,3 +34,26 @@ func (p *Presenter) FromEntityListToDAOList(orders []entity.Order) []dto.OrderDA
 	}
 	return ordersDAO
 }
+
+func (p *Presenter) FromEntityListToPage(orders []entity.Order, filter dto.OrderFilterDTO, total int64) dto.OrderResponseListDTO {
+	ordersDAO := make([]dto.OrderDAO, 0, len(orders))
+	for _, order := range orders {
+		ordersDAO = append(ordersDAO, dto.ToOrderDAO(order))
+	}
+	pagination := dto.NewPaginationDTO(filter, total)
+	return dto.OrderResponseListDTO{
+		Orders:     ordersDAO,
+		Pagination: &pagination,
+	}
+}
+
+func (p *Presenter) FromHistoryListToResponse(orderID string, history []entity.OrderStatusHistory) dto.OrderStatusHistoryResponseDTO {
+	historyDAO := make([]dto.OrderStatusHistoryDAO, 0, len(history))
+	for _, entry := range history {
+		historyDAO = append(historyDAO, dto.ToOrderStatusHistoryDAO(entry))
+	}
+	return dto.OrderStatusHistoryResponseDTO{
+		OrderID: orderID,
+		History: historyDAO,
+	}
+}
@@ -0,0 +1,153 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+)
+
+// outboxDeliveryTimeout bounds a single delivery, well within the lease that hides the entry from
+// other dispatchers
+const outboxDeliveryTimeout = 20 * time.Second
+
+type productOrdersPayload struct {
+	Products []entity.OrderProductInfo `json:"products"`
+}
+
+// buildOrderOutbox creates the outbox entries for every downstream call a new order requires
+func buildOrderOutbox(orderID string, products []entity.OrderProductInfo) ([]entity.OutboxEntry, error) {
+	productsPayload, err := json.Marshal(productOrdersPayload{Products: products})
+	if err != nil {
+		return nil, &apperror.InternalError{Msg: err.Error()}
+	}
+
+	return []entity.OutboxEntry{
+		entity.OutboxEntry{
+			OrderID: orderID,
+			Type:    enum.OutboxEventCreateProductOrders,
+			Payload: string(productsPayload),
+		}.Build(),
+		entity.OutboxEntry{
+			OrderID: orderID,
+			Type:    enum.OutboxEventCreatePayment,
+			Payload: "{}",
+		}.Build(),
+	}, nil
+}
+
+// DispatchOutbox delivers up to limit due outbox entries and returns how many were delivered
+func (u *UseCases) DispatchOutbox(ctx context.Context, limit int) (int, error) {
+	entries, err := u.orderGateway.ClaimDueOutboxEntries(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return u.deliverOutboxEntries(ctx, entries), nil
+}
+
+func (u *UseCases) GetStuckOutboxEntries(ctx context.Context) ([]entity.OutboxEntry, error) {
+	return u.orderGateway.GetStuckOutboxEntries(ctx)
+}
+
+// RetryOutboxEntry requeues a failed entry for immediate delivery by the dispatcher. Entries that are
+// still pending are retried by the dispatcher on their own, and an entry whose lease has not expired
+// may still be in delivery, so both are rejected.
+func (u *UseCases) RetryOutboxEntry(ctx context.Context, id string) (entity.OutboxEntry, error) {
+	entry, err := u.orderGateway.FindOutboxEntryByID(ctx, id)
+	if err != nil {
+		return entity.OutboxEntry{}, err
+	}
+
+	if entry.Status != enum.OutboxStatusFailed {
+		return entity.OutboxEntry{}, &apperror.ConflictError{Msg: fmt.Sprintf("only failed outbox entries can be retried, this one is %s", entry.Status)}
+	}
+
+	now := time.Now()
+	requeued := entry.Requeue(now)
+	ok, err := u.orderGateway.RequeueFailedOutboxEntry(ctx, requeued, now)
+	if err != nil {
+		return entity.OutboxEntry{}, err
+	}
+	if !ok {
+		return entity.OutboxEntry{}, &apperror.ConflictError{Msg: "outbox entry is being delivered, try again once its lease expires"}
+	}
+
+	return requeued, nil
+}
+
+// deliverOutboxEntries delivers the claimed entries and records the outcome of each one. Deliveries
+// are detached from the caller's cancellation, so neither a client disconnect nor a dispatcher
+// shutdown abandons a call halfway with its outcome unrecorded.
+func (u *UseCases) deliverOutboxEntries(ctx context.Context, entries []entity.OutboxEntry) int {
+	delivered := 0
+	for _, entry := range entries {
+		if u.deliverAndRecord(context.WithoutCancel(ctx), entry) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// deliverAndRecord reports whether the entry was delivered. Only the downstream call is bounded
+// by the delivery timeout, so a slow call still leaves time to record its outcome.
+func (u *UseCases) deliverAndRecord(ctx context.Context, entry entity.OutboxEntry) bool {
+	deliveryCtx, cancel := context.WithTimeout(ctx, outboxDeliveryTimeout)
+	deliveryErr := u.deliverOutboxEntry(deliveryCtx, entry)
+	cancel()
+
+	if deliveryErr != nil {
+		entry = entry.MarkAttemptFailed(time.Now(), deliveryErr)
+		u.logger.WarnContext(ctx, "outbox delivery failed",
+			slog.String("outbox_id", entry.ID),
+			slog.String("order_id", entry.OrderID),
+			slog.String("type", string(entry.Type)),
+			slog.Int("attempts", entry.Attempts),
+			slog.String("status", string(entry.Status)),
+			slog.String("error", deliveryErr.Error()))
+	} else {
+		entry = entry.MarkDelivered(time.Now())
+	}
+
+	// If this update is lost the lease expires and the entry is delivered again
+	if err := u.orderGateway.UpdateOutboxEntry(ctx, entry); err != nil {
+		u.logger.ErrorContext(ctx, "outbox entry update failed, it will be delivered again",
+			slog.String("outbox_id", entry.ID),
+			slog.String("error", err.Error()))
+	}
+	return deliveryErr == nil
+}
+
+func (u *UseCases) deliverOutboxEntry(ctx context.Context, entry entity.OutboxEntry) error {
+	switch entry.Type {
+	case enum.OutboxEventCreateProductOrders:
+		var payload productOrdersPayload
+		if err := json.Unmarshal([]byte(entry.Payload), &payload); err != nil {
+			return err
+		}
+		return u.productOrderService.CreateBulk(ctx, entry.OrderID, payload.Products)
+	case enum.OutboxEventCreatePayment:
+		// The payment was saved by an earlier delivery whose outbox update was lost, or the order was
+		// cancelled before it needed one
+		order, err := u.orderGateway.FindByID(ctx, entry.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.PaymentID != "" || order.Status == enum.OrderStatusCancelled {
+			return nil
+		}
+
+		payment, err := u.paymentService.CreateByOrderID(ctx, entry.OrderID)
+		if err != nil {
+			return err
+		}
+		return u.orderGateway.SavePayment(ctx, entry.OrderID, payment)
+	default:
+		return fmt.Errorf("unknown outbox event type %q", entry.Type)
+	}
+}
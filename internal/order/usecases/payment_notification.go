@@ -0,0 +1,60 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+)
+
+// PaymentServiceActor identifies status changes triggered by payment notifications in the order history
+const PaymentServiceActor = "payment-service"
+
+// ApplyPaymentResult moves an order awaiting payment to received when the payment was approved, or to
+// payment_rejected otherwise. Notifications for orders that already left awaiting_payment are duplicates
+// or arrived too late, and are acknowledged without changes so the payment service stops retrying. The
+// status is only changed while the stored order is still awaiting payment, so of concurrent deliveries
+// of the same notification exactly one is applied.
+func (u *UseCases) ApplyPaymentResult(ctx context.Context, orderID string, approved bool) (entity.Order, bool, error) {
+	current, err := u.orderGateway.FindByID(ctx, orderID)
+	if err != nil {
+		return entity.Order{}, false, err
+	}
+
+	if current.Status != enum.OrderStatusAwaitingPayment {
+		u.logger.InfoContext(ctx, "payment notification ignored, order is no longer awaiting payment",
+			slog.String("order_id", orderID),
+			slog.String("status", current.Status.String()),
+			slog.Bool("approved", approved))
+		return current, false, nil
+	}
+
+	next := current
+	next.Status = enum.OrderStatusPaymentRejected
+	if approved {
+		next.Status = enum.OrderStatusReceived
+	}
+
+	updated, err := u.changeStatus(ctx, current, next, PaymentServiceActor)
+	var conflict *apperror.ConflictError
+	if errors.As(err, &conflict) {
+		// A concurrent delivery moved the order out of awaiting_payment first
+		u.logger.InfoContext(ctx, "payment notification ignored, it was applied concurrently",
+			slog.String("order_id", orderID),
+			slog.Bool("approved", approved))
+
+		latest, findErr := u.orderGateway.FindByID(ctx, orderID)
+		if findErr != nil {
+			return entity.Order{}, false, findErr
+		}
+		return latest, false, nil
+	}
+	if err != nil {
+		return entity.Order{}, false, err
+	}
+
+	return updated, true, nil
+}
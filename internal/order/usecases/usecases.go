@@ -2,19 +2,31 @@ package usecases
 
 import (
 	"context"
+	"log/slog"
+	"time"
 
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/dto"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/gateway"
 	"github.com/fiap-161/tc-golunch-operation-service/internal/order/interfaces"
 	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// CustomerActor identifies orders placed without a customer ID in the order history
+const CustomerActor = "customer"
+
 type UseCases struct {
 	orderGateway        *gateway.Gateway
 	productService      interfaces.ProductService
 	productOrderService interfaces.ProductOrderService
 	paymentService      interfaces.PaymentService
+	changeNotifier      interfaces.OrderChangeNotifier
+	estimator           *entity.PreparationEstimator
+	metrics             interfaces.OrderMetrics
+	logger              *slog.Logger
 }
 
 func Build(
@@ -22,16 +34,30 @@ func Build(
 	productService interfaces.ProductService,
 	productOrderService interfaces.ProductOrderService,
 	paymentService interfaces.PaymentService,
+	changeNotifier interfaces.OrderChangeNotifier,
+	estimator *entity.PreparationEstimator,
+	metrics interfaces.OrderMetrics,
+	logger *slog.Logger,
 ) *UseCases {
 	return &UseCases{
 		orderGateway:        orderGateway,
 		productService:      productService,
 		productOrderService: productOrderService,
 		paymentService:      paymentService,
+		changeNotifier:      changeNotifier,
+		estimator:           estimator,
+		metrics:             metrics,
+		logger:              logger,
 	}
 }
 
-func (u *UseCases) CreateCompleteOrder(ctx context.Context, orderDTO dto.CreateOrderDTO) (string, error) {
+// CreateCompleteOrder creates the order and tries its downstream calls right away. The returned order
+// carries the payment QR code unless the payment could not be created yet, in which case the outbox
+// dispatcher keeps trying and the QR code can be fetched later.
+func (u *UseCases) CreateCompleteOrder(ctx context.Context, orderDTO dto.CreateOrderDTO) (_ entity.Order, err error) {
+	ctx, span := tracing.Start(ctx, "UseCases.CreateCompleteOrder", attribute.Int("order.products", len(orderDTO.Products)))
+	defer func() { tracing.End(span, err) }()
+
 	var productIds []string
 	for _, item := range orderDTO.Products {
 		productIds = append(productIds, item.ProductID)
@@ -39,20 +65,29 @@ func (u *UseCases) CreateCompleteOrder(ctx context.Context, orderDTO dto.CreateO
 
 	products, findErr := u.productService.FindByIDs(ctx, productIds)
 	if findErr != nil {
-		return "", findErr
+		return entity.Order{}, findErr
+	}
+
+	// A product may be requested on several lines, so every line is checked against the products found
+	found := make(map[string]bool, len(products))
+	for _, product := range products {
+		found[product.Id] = true
 	}
-	if len(products) != len(orderDTO.Products) {
-		return "", &apperror.NotFoundError{
-			Msg: "some products not found",
+	for _, item := range orderDTO.Products {
+		if !found[item.ProductID] {
+			return entity.Order{}, &apperror.NotFoundError{
+				Msg: "some products not found",
+			}
 		}
 	}
 
 	// Criar pedido
-	populatedOrder := generateOrderByProducts(orderDTO, products)
-	createdOrder, createErr := u.orderGateway.Create(ctx, populatedOrder.Build())
-	if createErr != nil {
-		return "", createErr
+	generatedOrder, generateErr := generateOrderByProducts(orderDTO, products)
+	if generateErr != nil {
+		return entity.Order{}, &apperror.ValidationError{Msg: generateErr.Error()}
 	}
+	// Built first, so the queue the order is estimated behind leaves out the order by its ID
+	populatedOrder := u.estimate(ctx, generatedOrder.Build())
 
 	// Converter para entity.OrderProductInfo para a interface
 	orderProductInfo := make([]entity.OrderProductInfo, len(orderDTO.Products))
@@ -60,46 +95,83 @@ func (u *UseCases) CreateCompleteOrder(ctx context.Context, orderDTO dto.CreateO
 		orderProductInfo[i] = entity.OrderProductInfo{
 			ProductID: product.ProductID,
 			Quantity:  product.Quantity,
+			Notes:     product.Notes,
 		}
 	}
 
-	createBulkErr := u.productOrderService.CreateBulk(ctx, createdOrder.ID, orderProductInfo)
-	if createBulkErr != nil {
-		return "", createBulkErr
+	outbox, outboxErr := buildOrderOutbox(populatedOrder.ID, orderProductInfo)
+	if outboxErr != nil {
+		return entity.Order{}, outboxErr
+	}
+
+	createdOrder, createErr := u.orderGateway.Create(ctx, populatedOrder, initialHistory(populatedOrder), outbox)
+	if createErr != nil {
+		return entity.Order{}, createErr
 	}
+	span.SetAttributes(attribute.String("order.id", createdOrder.ID))
+
+	u.logger.InfoContext(ctx, "order created",
+		slog.String("order_id", createdOrder.ID),
+		slog.String("customer_id", createdOrder.CustomerID),
+		slog.String("price", createdOrder.Price.String()),
+		slog.Uint64("preparing_time", uint64(createdOrder.PreparingTime)))
+
+	// Downstream calls are attempted right away, even if the client has gone; failures stay in the
+	// outbox for the dispatcher
+	u.deliverOutboxEntries(ctx, outbox)
 
-	paymentErr := u.paymentService.CreateByOrderID(ctx, createdOrder.ID)
-	if paymentErr != nil {
-		return "", paymentErr
+	u.changeNotifier.NotifyOrderChanged(createdOrder)
+
+	withPayment, findErr := u.orderGateway.FindByID(ctx, createdOrder.ID)
+	if findErr != nil {
+		return createdOrder, nil
 	}
 
-	return "payment-qr-code-placeholder", nil
+	return withPayment, nil
 }
 
-func generateOrderByProducts(orderDTO dto.CreateOrderDTO, products []entity.Product) entity.Order {
+func generateOrderByProducts(orderDTO dto.CreateOrderDTO, products []entity.Product) (entity.Order, error) {
 	orderProductInfo := make([]entity.OrderProductInfo, len(orderDTO.Products))
 	for i, product := range orderDTO.Products {
 		orderProductInfo[i] = entity.OrderProductInfo{
 			ProductID: product.ProductID,
 			Quantity:  product.Quantity,
+			Notes:     product.Notes,
 		}
 	}
 
 	return entity.Order{}.FromDTO(orderDTO.CustomerID, orderProductInfo, products)
 }
 func (u *UseCases) CreateOrder(ctx context.Context, order entity.Order) (entity.Order, error) {
-	return u.orderGateway.Create(ctx, order)
+	return u.orderGateway.Create(ctx, order, initialHistory(order), nil)
 }
 
-func (u *UseCases) GetAllOrById(ctx context.Context, id string) ([]entity.Order, error) {
-	if id != "" {
-		order, err := u.orderGateway.FindByID(ctx, id)
+// initialHistory records the order entering its first status, placed by its customer
+func initialHistory(order entity.Order) entity.OrderStatusHistory {
+	changedBy := order.CustomerID
+	if changedBy == "" {
+		changedBy = CustomerActor
+	}
+
+	return entity.OrderStatusHistory{
+		OrderID:   order.ID,
+		ToStatus:  order.Status,
+		ChangedBy: changedBy,
+	}.Build()
+}
+
+// GetAllOrById returns the order matching filter.ID when it is set, otherwise one page of orders
+// matching the normalized filter. The second return value is the total number of matching orders.
+func (u *UseCases) GetAllOrById(ctx context.Context, filter dto.OrderFilterDTO) ([]entity.Order, int64, error) {
+	if filter.ID != "" {
+		order, err := u.orderGateway.FindByID(ctx, filter.ID)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
-		return []entity.Order{order}, nil
+		return []entity.Order{order}, 1, nil
 	}
-	return u.orderGateway.GetAll(ctx)
+
+	return u.orderGateway.GetAll(ctx, filter)
 }
 
 func (u *UseCases) GetPanel(ctx context.Context) ([]entity.Order, error) {
@@ -110,6 +182,109 @@ func (u *UseCases) FindByID(ctx context.Context, id string) (entity.Order, error
 	return u.orderGateway.FindByID(ctx, id)
 }
 
-func (u *UseCases) Update(ctx context.Context, order entity.Order) (entity.Order, error) {
-	return u.orderGateway.Update(ctx, order)
+func (u *UseCases) Update(ctx context.Context, order entity.Order, changedBy string) (entity.Order, error) {
+	current, err := u.orderGateway.FindByID(ctx, order.ID)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	if order.Status == enum.OrderStatusCancelled {
+		return entity.Order{}, &apperror.ValidationError{Msg: "orders must be cancelled through the cancellation flow"}
+	}
+
+	return u.changeStatus(ctx, current, order, changedBy)
+}
+
+// Cancel moves the order to cancelled and refunds its payment. The refund is requested first so a
+// failure leaves the order untouched and the cancellation can be retried. The payment service applies
+// the refund of an order once, so a retry after the refund went through does not refund it again.
+func (u *UseCases) Cancel(ctx context.Context, orderID string, reason string, changedBy string) (entity.Order, error) {
+	if reason == "" {
+		return entity.Order{}, &apperror.ValidationError{Msg: "cancellation reason is required"}
+	}
+
+	current, err := u.orderGateway.FindByID(ctx, orderID)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	if err := current.Status.ValidateTransition(enum.OrderStatusCancelled); err != nil {
+		return entity.Order{}, &apperror.ValidationError{Msg: err.Error()}
+	}
+
+	// An order still awaiting the creation of its payment has nothing to refund, and the outbox
+	// no longer creates a payment once it is cancelled
+	if current.PaymentID != "" {
+		if err := u.paymentService.RefundByOrderID(ctx, orderID, reason); err != nil {
+			u.logger.ErrorContext(ctx, "order refund failed, order was not cancelled",
+				slog.String("order_id", orderID),
+				slog.String("error", err.Error()))
+			return entity.Order{}, err
+		}
+	}
+
+	return u.changeStatus(ctx, current, current.Cancel(reason), changedBy)
+}
+
+func (u *UseCases) changeStatus(ctx context.Context, current entity.Order, order entity.Order, changedBy string) (entity.Order, error) {
+	// An invalid or illegal status is a bad request listing the allowed ones. Conflict is kept for a
+	// change that lost the race against a concurrent one.
+	if err := current.Status.ValidateTransition(order.Status); err != nil {
+		return entity.Order{}, &apperror.ValidationError{Msg: err.Error()}
+	}
+
+	// The kitchen starts on the order once it is received, so the ready time is estimated again
+	// against the queue at that moment
+	if order.Status == enum.OrderStatusReceived {
+		order = u.estimate(ctx, order)
+	}
+
+	history := entity.OrderStatusHistory{
+		OrderID:    order.ID,
+		FromStatus: current.Status,
+		ToStatus:   order.Status,
+		ChangedBy:  changedBy,
+	}.Build()
+	order.StatusChangedAt = &history.ChangedAt
+
+	updated, err := u.orderGateway.Update(ctx, order, history)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	u.logger.InfoContext(ctx, "order status changed",
+		slog.String("order_id", updated.ID),
+		slog.String("from", current.Status.String()),
+		slog.String("to", updated.Status.String()),
+		slog.String("changed_by", changedBy))
+
+	u.metrics.ObserveStatusChange(current.Status, updated.Status, history.ChangedAt.Sub(current.StatusEnteredAt()))
+	u.changeNotifier.NotifyOrderChanged(updated)
+	return updated, nil
+}
+
+// CountByStatus returns how many orders are currently in each status
+func (u *UseCases) CountByStatus(ctx context.Context) (map[enum.OrderStatus]int64, error) {
+	return u.orderGateway.CountByStatus(ctx)
+}
+
+// estimate applies the preparation estimate of the order behind the work queued in the kitchen.
+// When the queue cannot be read the order is estimated as if the kitchen were idle.
+func (u *UseCases) estimate(ctx context.Context, order entity.Order) entity.Order {
+	queue, err := u.orderGateway.KitchenQueueWork(ctx, order.ID)
+	if err != nil {
+		u.logger.WarnContext(ctx, "kitchen queue unavailable, estimating as an idle kitchen",
+			slog.String("order_id", order.ID),
+			slog.String("error", err.Error()))
+	}
+
+	return order.ApplyEstimate(u.estimator.Estimate(order.Items, queue), time.Now())
+}
+
+func (u *UseCases) GetStatusHistory(ctx context.Context, orderID string) ([]entity.OrderStatusHistory, error) {
+	if _, err := u.orderGateway.FindByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	return u.orderGateway.GetStatusHistory(ctx, orderID)
 }
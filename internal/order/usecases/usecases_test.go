@@ -0,0 +1,726 @@
+package usecases
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/dto"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity/enum"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/external/datasource"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/gateway"
+	sharedentity "github.com/fiap-161/tc-golunch-operation-service/internal/shared/entity"
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/money"
+)
+
+// fakeDataSource keeps orders in memory and applies status changes only from the expected status,
+// like the database does
+type fakeDataSource struct {
+	datasource.DataSource
+	mu      sync.Mutex
+	orders  map[string]dto.OrderDAO
+	history []dto.OrderStatusHistoryDAO
+	outbox  map[string]dto.OutboxEntryDAO
+	queue   []dto.StationWorkDAO
+	// excluded records the order IDs KitchenQueueWork was asked to leave out
+	excluded []string
+	// beforeUpdate runs right before a status change is applied, to simulate a concurrent change
+	beforeUpdate func(f *fakeDataSource)
+}
+
+func newFakeDataSource(orders ...dto.OrderDAO) *fakeDataSource {
+	f := &fakeDataSource{orders: map[string]dto.OrderDAO{}, outbox: map[string]dto.OutboxEntryDAO{}}
+	for _, order := range orders {
+		f.orders[order.ID] = order
+	}
+	return f
+}
+
+func (f *fakeDataSource) Create(_ context.Context, order dto.OrderDAO, history dto.OrderStatusHistoryDAO, outbox []dto.OutboxEntryDAO) (dto.OrderDAO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.orders[order.ID] = order
+	f.history = append(f.history, history)
+	for _, entry := range outbox {
+		f.outbox[entry.ID] = entry
+	}
+	return order, nil
+}
+
+func (f *fakeDataSource) UpdatePayment(_ context.Context, orderID string, paymentID string, qrCode string, expiresAt *time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order := f.orders[orderID]
+	order.PaymentID = paymentID
+	order.QRCode = qrCode
+	order.QRCodeExpiresAt = expiresAt
+	f.orders[orderID] = order
+	return nil
+}
+
+func (f *fakeDataSource) ClaimDueOutboxEntries(_ context.Context, now time.Time, limit int, lease time.Duration) ([]dto.OutboxEntryDAO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var claimed []dto.OutboxEntryDAO
+	for id, entry := range f.outbox {
+		if len(claimed) < limit && entry.Status == enum.OutboxStatusPending && !entry.NextAttemptAt.After(now) {
+			entry.NextAttemptAt = now.Add(lease)
+			f.outbox[id] = entry
+			claimed = append(claimed, entry)
+		}
+	}
+	return claimed, nil
+}
+
+func (f *fakeDataSource) UpdateOutboxEntry(_ context.Context, entry dto.OutboxEntryDAO) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.outbox[entry.ID] = entry
+	return nil
+}
+
+func (f *fakeDataSource) RequeueFailedOutboxEntry(_ context.Context, entry dto.OutboxEntryDAO, now time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored := f.outbox[entry.ID]
+	if stored.Status != enum.OutboxStatusFailed || stored.NextAttemptAt.After(now) {
+		return false, nil
+	}
+	f.outbox[entry.ID] = entry
+	return true, nil
+}
+
+func (f *fakeDataSource) FindOutboxEntryByID(_ context.Context, id string) (dto.OutboxEntryDAO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry, ok := f.outbox[id]
+	if !ok {
+		return dto.OutboxEntryDAO{}, gorm.ErrRecordNotFound
+	}
+	return entry, nil
+}
+
+func (f *fakeDataSource) FindByID(_ context.Context, id string) (dto.OrderDAO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order, ok := f.orders[id]
+	if !ok {
+		return dto.OrderDAO{}, gorm.ErrRecordNotFound
+	}
+	return order, nil
+}
+
+func (f *fakeDataSource) KitchenQueueWork(_ context.Context, excludeOrderID string) ([]dto.StationWorkDAO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.excluded = append(f.excluded, excludeOrderID)
+	return f.queue, nil
+}
+
+func (f *fakeDataSource) GetPanel(context.Context) ([]dto.OrderDAO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var panel []dto.OrderDAO
+	for _, order := range f.orders {
+		if order.Status != enum.OrderStatusCompleted && order.Status != enum.OrderStatusCancelled && order.Status != enum.OrderStatusPaymentRejected {
+			panel = append(panel, order)
+		}
+	}
+	return panel, nil
+}
+
+func (f *fakeDataSource) Update(_ context.Context, order dto.OrderDAO, history dto.OrderStatusHistoryDAO) (dto.OrderDAO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.beforeUpdate != nil {
+		f.beforeUpdate(f)
+	}
+
+	stored := f.orders[order.ID]
+	if stored.Status != history.FromStatus {
+		return dto.OrderDAO{}, datasource.ErrStatusChanged
+	}
+
+	stored.Status = order.Status
+	stored.CancellationReason = order.CancellationReason
+	stored.PreparingTime = order.PreparingTime
+	stored.EstimatedReadyAt = order.EstimatedReadyAt
+	stored.StatusChangedAt = order.StatusChangedAt
+	f.orders[order.ID] = stored
+	f.history = append(f.history, history)
+	return stored, nil
+}
+
+func (f *fakeDataSource) GetStatusHistory(_ context.Context, orderID string) ([]dto.OrderStatusHistoryDAO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var history []dto.OrderStatusHistoryDAO
+	for _, entry := range f.history {
+		if entry.OrderID == orderID {
+			history = append(history, entry)
+		}
+	}
+	return history, nil
+}
+
+type fakeProductService []entity.Product
+
+func (f fakeProductService) FindByIDs(context.Context, []string) ([]entity.Product, error) {
+	return f, nil
+}
+
+type noopProductOrderService struct{}
+
+func (noopProductOrderService) CreateBulk(context.Context, string, []entity.OrderProductInfo) error {
+	return nil
+}
+
+type fakePaymentService struct {
+	createErr error
+	creates   int
+	refundErr error
+	refunds   int
+}
+
+func (f *fakePaymentService) CreateByOrderID(ctx context.Context, orderID string) (entity.Payment, error) {
+	f.creates++
+	if err := ctx.Err(); err != nil {
+		return entity.Payment{}, err
+	}
+	if f.createErr != nil {
+		return entity.Payment{}, f.createErr
+	}
+	return entity.Payment{ID: "payment-" + orderID, QRCode: "qr-" + orderID}, nil
+}
+
+func (f *fakePaymentService) RefundByOrderID(context.Context, string, string) error {
+	f.refunds++
+	return f.refundErr
+}
+
+type noopNotifier struct{}
+
+func (noopNotifier) NotifyOrderChanged(entity.Order) {}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveStatusChange(enum.OrderStatus, enum.OrderStatus, time.Duration) {}
+
+// recordingMetrics keeps the time in status of each observed change
+type recordingMetrics struct {
+	timeInStatus []time.Duration
+}
+
+func (r *recordingMetrics) ObserveStatusChange(_ enum.OrderStatus, _ enum.OrderStatus, timeInStatus time.Duration) {
+	r.timeInStatus = append(r.timeInStatus, timeInStatus)
+}
+
+func newTestUseCases(ds datasource.DataSource, payments *fakePaymentService) *UseCases {
+	return Build(
+		gateway.Build(ds),
+		fakeProductService{{Id: "burger", Name: "Burger", Price: money.New(2500, money.DefaultCurrency), PreparingTime: 10, Station: "grill"}},
+		noopProductOrderService{},
+		payments,
+		noopNotifier{},
+		entity.NewPreparationEstimator(entity.PreparationEstimatorConfig{}),
+		noopMetrics{},
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+}
+
+func TestUseCases_Update_StatusChangedConcurrently(t *testing.T) {
+	ds := newFakeDataSource(dto.OrderDAO{
+		Entity: sharedentity.Entity{ID: "order-1"},
+		Status: enum.OrderStatusInPreparation,
+	})
+	// The order is cancelled after it was read for the transition check
+	ds.beforeUpdate = func(f *fakeDataSource) {
+		cancelled := f.orders["order-1"]
+		cancelled.Status = enum.OrderStatusCancelled
+		f.orders["order-1"] = cancelled
+	}
+	useCases := newTestUseCases(ds, &fakePaymentService{})
+
+	_, err := useCases.Update(context.Background(), entity.Order{
+		Entity: sharedentity.Entity{ID: "order-1"},
+		Status: enum.OrderStatusReady,
+	}, "kitchen")
+
+	assert.IsType(t, &apperror.ConflictError{}, err)
+	assert.Equal(t, enum.OrderStatusCancelled, ds.orders["order-1"].Status)
+	assert.Empty(t, ds.history)
+}
+
+func TestUseCases_Update_InvalidTransition(t *testing.T) {
+	tests := []struct {
+		name   string
+		status enum.OrderStatus
+	}{
+		{name: "unknown status", status: "banana"},
+		{name: "status skipped", status: enum.OrderStatusCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := newFakeDataSource(dto.OrderDAO{
+				Entity: sharedentity.Entity{ID: "order-1"},
+				Status: enum.OrderStatusInPreparation,
+			})
+			useCases := newTestUseCases(ds, &fakePaymentService{})
+
+			_, err := useCases.Update(context.Background(), entity.Order{
+				Entity: sharedentity.Entity{ID: "order-1"},
+				Status: tt.status,
+			}, "kitchen")
+
+			assert.IsType(t, &apperror.ValidationError{}, err)
+			assert.Equal(t, enum.OrderStatusInPreparation, ds.orders["order-1"].Status)
+		})
+	}
+}
+
+func TestUseCases_Update_TimeInStatus(t *testing.T) {
+	enteredAt := time.Now().Add(-90 * time.Second)
+	ds := newFakeDataSource(dto.OrderDAO{
+		Entity:          sharedentity.Entity{ID: "order-1", CreatedAt: enteredAt.Add(-time.Hour)},
+		Status:          enum.OrderStatusInPreparation,
+		StatusChangedAt: &enteredAt,
+	})
+	metrics := &recordingMetrics{}
+	useCases := newTestUseCases(ds, &fakePaymentService{})
+	useCases.metrics = metrics
+
+	updated, err := useCases.Update(context.Background(), entity.Order{
+		Entity: sharedentity.Entity{ID: "order-1"},
+		Status: enum.OrderStatusReady,
+	}, "kitchen")
+
+	assert.NoError(t, err)
+	assert.Len(t, metrics.timeInStatus, 1)
+	assert.InDelta(t, 90*time.Second, metrics.timeInStatus[0], float64(time.Second))
+	assert.Equal(t, ds.history[0].ChangedAt, updated.StatusEnteredAt())
+}
+
+func TestUseCases_StatusHistory(t *testing.T) {
+	ctx := context.Background()
+	ds := newFakeDataSource()
+	payments := &fakePaymentService{}
+	useCases := newTestUseCases(ds, payments)
+
+	created, err := useCases.CreateCompleteOrder(ctx, dto.CreateOrderDTO{
+		CustomerID: "customer-1",
+		Products:   []dto.OrderProductInfo{{ProductID: "burger", Quantity: 1}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, payments.creates)
+	assert.Equal(t, "qr-"+created.ID, created.QRCode, "the payment is persisted on the order")
+
+	received := created
+	received.Status = enum.OrderStatusReceived
+	_, err = useCases.Update(ctx, received, "staff-1")
+	assert.NoError(t, err)
+
+	history, err := useCases.GetStatusHistory(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	steps := make([][3]string, 0, len(history))
+	for _, entry := range history {
+		assert.Equal(t, created.ID, entry.OrderID)
+		steps = append(steps, [3]string{entry.FromStatus.String(), entry.ToStatus.String(), entry.ChangedBy})
+	}
+	assert.Equal(t, [][3]string{
+		{"", "awaiting_payment", "customer-1"},
+		{"awaiting_payment", "received", "staff-1"},
+	}, steps)
+
+	_, err = useCases.GetStatusHistory(ctx, "missing")
+	assert.IsType(t, &apperror.NotFoundError{}, err)
+}
+
+func TestUseCases_Cancel(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      enum.OrderStatus
+		paymentID   string
+		reason      string
+		refundErr   error
+		wantErr     error
+		wantRefunds int
+		wantStatus  enum.OrderStatus
+	}{
+		{
+			name:        "cancels and refunds",
+			status:      enum.OrderStatusReceived,
+			paymentID:   "payment-1",
+			reason:      "customer gave up",
+			wantRefunds: 1,
+			wantStatus:  enum.OrderStatusCancelled,
+		},
+		{
+			name:        "pending payment is refunded",
+			status:      enum.OrderStatusAwaitingPayment,
+			paymentID:   "payment-1",
+			reason:      "customer gave up",
+			wantRefunds: 1,
+			wantStatus:  enum.OrderStatusCancelled,
+		},
+		{
+			name:       "order without payment is not refunded",
+			status:     enum.OrderStatusAwaitingPayment,
+			reason:     "customer gave up",
+			wantStatus: enum.OrderStatusCancelled,
+		},
+		{
+			name:        "refund failure keeps the order",
+			status:      enum.OrderStatusReceived,
+			paymentID:   "payment-1",
+			reason:      "customer gave up",
+			refundErr:   &apperror.UnavailableError{Msg: "payment service unavailable"},
+			wantErr:     &apperror.UnavailableError{},
+			wantRefunds: 1,
+			wantStatus:  enum.OrderStatusReceived,
+		},
+		{
+			name:       "invalid transition is not refunded",
+			status:     enum.OrderStatusReady,
+			reason:     "customer gave up",
+			wantErr:    &apperror.ValidationError{},
+			wantStatus: enum.OrderStatusReady,
+		},
+		{
+			name:       "empty reason is rejected",
+			status:     enum.OrderStatusReceived,
+			wantErr:    &apperror.ValidationError{},
+			wantStatus: enum.OrderStatusReceived,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := newFakeDataSource(dto.OrderDAO{Entity: sharedentity.Entity{ID: "order-1"}, Status: tt.status, PaymentID: tt.paymentID})
+			payments := &fakePaymentService{refundErr: tt.refundErr}
+
+			cancelled, err := newTestUseCases(ds, payments).Cancel(context.Background(), "order-1", tt.reason, "staff-1")
+
+			assert.Equal(t, tt.wantRefunds, payments.refunds)
+			assert.Equal(t, tt.wantStatus, ds.orders["order-1"].Status)
+			if tt.wantErr != nil {
+				assert.IsType(t, tt.wantErr, err)
+				assert.Empty(t, ds.history)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.reason, cancelled.CancellationReason)
+			assert.Len(t, ds.history, 1)
+		})
+	}
+}
+
+func TestUseCases_DispatchOutbox(t *testing.T) {
+	tests := []struct {
+		name          string
+		attempts      int
+		createErr     error
+		wantDelivered int
+		wantStatus    enum.OutboxStatus
+		wantAttempts  int
+		wantPaymentID string
+	}{
+		{
+			name:          "delivers the payment",
+			wantDelivered: 1,
+			wantStatus:    enum.OutboxStatusDelivered,
+			wantAttempts:  1,
+			wantPaymentID: "payment-order-1",
+		},
+		{
+			name:         "failure is retried with backoff",
+			createErr:    &apperror.UnavailableError{Msg: "payment service unavailable"},
+			wantStatus:   enum.OutboxStatusPending,
+			wantAttempts: 1,
+		},
+		{
+			name:         "last attempt marks the entry as failed",
+			attempts:     entity.OutboxMaxAttempts - 1,
+			createErr:    &apperror.UnavailableError{Msg: "payment service unavailable"},
+			wantStatus:   enum.OutboxStatusFailed,
+			wantAttempts: entity.OutboxMaxAttempts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := newFakeDataSource(dto.OrderDAO{Entity: sharedentity.Entity{ID: "order-1"}, Status: enum.OrderStatusAwaitingPayment})
+			ds.outbox["entry-1"] = dto.OutboxEntryDAO{
+				ID:            "entry-1",
+				OrderID:       "order-1",
+				Type:          enum.OutboxEventCreatePayment,
+				Status:        enum.OutboxStatusPending,
+				Attempts:      tt.attempts,
+				NextAttemptAt: time.Now().Add(-time.Second),
+			}
+			useCases := newTestUseCases(ds, &fakePaymentService{createErr: tt.createErr})
+
+			start := time.Now()
+			delivered, err := useCases.DispatchOutbox(context.Background(), 10)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDelivered, delivered)
+
+			entry := ds.outbox["entry-1"]
+			assert.Equal(t, tt.wantStatus, entry.Status)
+			assert.Equal(t, tt.wantAttempts, entry.Attempts)
+			assert.Equal(t, tt.wantPaymentID, ds.orders["order-1"].PaymentID)
+			if tt.wantStatus == enum.OutboxStatusPending {
+				assert.WithinDuration(t, start.Add(5*time.Second), entry.NextAttemptAt, time.Second)
+			}
+
+			// Nothing is due again right away
+			delivered, err = useCases.DispatchOutbox(context.Background(), 10)
+			assert.NoError(t, err)
+			assert.Zero(t, delivered)
+			assert.Equal(t, tt.wantAttempts, ds.outbox["entry-1"].Attempts)
+		})
+	}
+}
+
+func TestUseCases_DispatchOutbox_PaymentAlreadySaved(t *testing.T) {
+	ds := newFakeDataSource(dto.OrderDAO{
+		Entity:    sharedentity.Entity{ID: "order-1"},
+		Status:    enum.OrderStatusAwaitingPayment,
+		PaymentID: "payment-1",
+		QRCode:    "qr-1",
+	})
+	// The entry was delivered but marking it delivered was lost, so its lease expired
+	ds.outbox["entry-1"] = dto.OutboxEntryDAO{
+		ID:            "entry-1",
+		OrderID:       "order-1",
+		Type:          enum.OutboxEventCreatePayment,
+		Status:        enum.OutboxStatusPending,
+		NextAttemptAt: time.Now().Add(-time.Second),
+	}
+	payments := &fakePaymentService{}
+
+	delivered, err := newTestUseCases(ds, payments).DispatchOutbox(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Zero(t, payments.creates)
+	assert.Equal(t, enum.OutboxStatusDelivered, ds.outbox["entry-1"].Status)
+	assert.Equal(t, "qr-1", ds.orders["order-1"].QRCode)
+}
+
+func TestUseCases_DispatchOutbox_OrderCancelled(t *testing.T) {
+	ds := newFakeDataSource(dto.OrderDAO{
+		Entity: sharedentity.Entity{ID: "order-1"},
+		Status: enum.OrderStatusCancelled,
+	})
+	// The order was cancelled, without a refund, before its payment was created
+	ds.outbox["entry-1"] = dto.OutboxEntryDAO{
+		ID:            "entry-1",
+		OrderID:       "order-1",
+		Type:          enum.OutboxEventCreatePayment,
+		Status:        enum.OutboxStatusPending,
+		NextAttemptAt: time.Now().Add(-time.Second),
+	}
+	payments := &fakePaymentService{}
+
+	delivered, err := newTestUseCases(ds, payments).DispatchOutbox(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Zero(t, payments.creates)
+	assert.Empty(t, ds.orders["order-1"].PaymentID)
+}
+
+func TestUseCases_DispatchOutbox_CallerCancelled(t *testing.T) {
+	ds := newFakeDataSource(dto.OrderDAO{
+		Entity: sharedentity.Entity{ID: "order-1"},
+		Status: enum.OrderStatusAwaitingPayment,
+	})
+	ds.outbox["entry-1"] = dto.OutboxEntryDAO{
+		ID:            "entry-1",
+		OrderID:       "order-1",
+		Type:          enum.OutboxEventCreatePayment,
+		Status:        enum.OutboxStatusPending,
+		NextAttemptAt: time.Now().Add(-time.Second),
+	}
+	// The dispatcher is stopped while the batch is in flight
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	delivered, err := newTestUseCases(ds, &fakePaymentService{}).DispatchOutbox(ctx, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, enum.OutboxStatusDelivered, ds.outbox["entry-1"].Status)
+	assert.Equal(t, "payment-order-1", ds.orders["order-1"].PaymentID)
+}
+
+func TestUseCases_RetryOutboxEntry(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        enum.OutboxStatus
+		nextAttemptAt time.Time
+		wantErr       error
+	}{
+		{name: "failed entry is requeued", status: enum.OutboxStatusFailed, nextAttemptAt: time.Now().Add(-time.Second)},
+		{name: "leased entry is rejected", status: enum.OutboxStatusFailed, nextAttemptAt: time.Now().Add(time.Minute), wantErr: &apperror.ConflictError{}},
+		{name: "pending entry is rejected", status: enum.OutboxStatusPending, nextAttemptAt: time.Now().Add(-time.Second), wantErr: &apperror.ConflictError{}},
+		{name: "delivered entry is rejected", status: enum.OutboxStatusDelivered, nextAttemptAt: time.Now().Add(-time.Second), wantErr: &apperror.ConflictError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := newFakeDataSource()
+			ds.outbox["entry-1"] = dto.OutboxEntryDAO{ID: "entry-1", Status: tt.status, Attempts: 3, NextAttemptAt: tt.nextAttemptAt}
+
+			requeued, err := newTestUseCases(ds, &fakePaymentService{}).RetryOutboxEntry(context.Background(), "entry-1")
+
+			if tt.wantErr != nil {
+				assert.IsType(t, tt.wantErr, err)
+				assert.Equal(t, tt.status, ds.outbox["entry-1"].Status)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, enum.OutboxStatusPending, requeued.Status)
+			assert.Zero(t, ds.outbox["entry-1"].Attempts)
+		})
+	}
+}
+
+func TestUseCases_ApplyPaymentResult_ConcurrentDeliveries(t *testing.T) {
+	ds := newFakeDataSource(dto.OrderDAO{
+		Entity: sharedentity.Entity{ID: "order-1"},
+		Status: enum.OrderStatusAwaitingPayment,
+	})
+	// Both deliveries read the order before either one applies it
+	var read sync.WaitGroup
+	read.Add(2)
+	ds.beforeUpdate = func(f *fakeDataSource) {
+		f.mu.Unlock()
+		read.Done()
+		read.Wait()
+		f.mu.Lock()
+	}
+	useCases := newTestUseCases(ds, &fakePaymentService{})
+
+	applied := make([]bool, 2)
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range applied {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var order entity.Order
+			order, applied[i], errs[i] = useCases.ApplyPaymentResult(context.Background(), "order-1", true)
+			assert.Equal(t, enum.OrderStatusReceived, order.Status)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.ElementsMatch(t, []bool{true, false}, applied)
+	assert.Len(t, ds.history, 1)
+}
+
+func TestUseCases_ItemNotes(t *testing.T) {
+	ctx := context.Background()
+	useCases := newTestUseCases(newFakeDataSource(), &fakePaymentService{})
+
+	order := dto.CreateOrderDTO{Products: []dto.OrderProductInfo{{ProductID: "burger", Quantity: 2, Notes: "  sem cebola  "}}}
+	assert.NoError(t, order.Validate())
+	created, err := useCases.CreateCompleteOrder(ctx, order)
+	assert.NoError(t, err)
+
+	found, err := useCases.FindByID(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Len(t, found.Items, 1)
+	assert.Equal(t, "sem cebola", found.Items[0].Notes)
+
+	panel, err := useCases.GetPanel(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, panel, 1)
+	assert.Equal(t, []dto.OrderPanelLineDTO{{Name: "Burger", Quantity: 2, Notes: "sem cebola"}},
+		dto.ToOrderPanelItemDTO(dto.ToOrderDAO(panel[0])).Items)
+}
+
+func TestUseCases_EstimatesBehindTheKitchenQueue(t *testing.T) {
+	ds := newFakeDataSource()
+	ds.queue = []dto.StationWorkDAO{{Station: enum.KitchenStationGrill, Work: 30}, {Station: enum.KitchenStationFryer, Work: 60}}
+	useCases := newTestUseCases(ds, &fakePaymentService{})
+
+	start := time.Now()
+	created, err := useCases.CreateCompleteOrder(context.Background(), dto.CreateOrderDTO{
+		Products: []dto.OrderProductInfo{{ProductID: "burger", Quantity: 1}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(10), created.PreparingTime)
+	assert.WithinDuration(t, start.Add(40*time.Minute), *created.EstimatedReadyAt, time.Second)
+	assert.Equal(t, []string{created.ID}, ds.excluded, "the queue leaves out the new order by its ID")
+	assert.NotEmpty(t, created.ID)
+}
+
+func TestUseCases_CreateCompleteOrder_Products(t *testing.T) {
+	tests := []struct {
+		name      string
+		products  []dto.OrderProductInfo
+		wantErr   error
+		wantPrice string
+		wantItems int
+	}{
+		{
+			name: "repeated product on several lines",
+			products: []dto.OrderProductInfo{
+				{ProductID: "burger", Quantity: 1, Notes: "sem cebola"},
+				{ProductID: "burger", Quantity: 2},
+			},
+			wantPrice: "75.00",
+			wantItems: 2,
+		},
+		{
+			name: "unknown product",
+			products: []dto.OrderProductInfo{
+				{ProductID: "burger", Quantity: 1},
+				{ProductID: "pizza", Quantity: 1},
+			},
+			wantErr: &apperror.NotFoundError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCases := newTestUseCases(newFakeDataSource(), &fakePaymentService{})
+
+			created, err := useCases.CreateCompleteOrder(context.Background(), dto.CreateOrderDTO{Products: tt.products})
+
+			if tt.wantErr != nil {
+				assert.IsType(t, tt.wantErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPrice, created.Price.String())
+			assert.Len(t, created.Items, tt.wantItems)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultOutboxInterval  = 5 * time.Second
+	DefaultOutboxBatchSize = 20
+)
+
+// OutboxDispatcherUseCase is implemented by the order use cases
+type OutboxDispatcherUseCase interface {
+	DispatchOutbox(ctx context.Context, limit int) (int, error)
+}
+
+// OutboxDispatcher periodically delivers the due entries of the order outbox in the background
+type OutboxDispatcher struct {
+	useCase   OutboxDispatcherUseCase
+	interval  time.Duration
+	batchSize int
+	logger    *slog.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewOutboxDispatcher(useCase OutboxDispatcherUseCase, interval time.Duration, batchSize int, logger *slog.Logger) *OutboxDispatcher {
+	if interval <= 0 {
+		interval = DefaultOutboxInterval
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultOutboxBatchSize
+	}
+
+	return &OutboxDispatcher{
+		useCase:   useCase,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Start runs the dispatcher loop until Stop is called or ctx is cancelled
+func (d *OutboxDispatcher) Start(ctx context.Context) {
+	ctx, d.cancel = context.WithCancel(ctx)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.drain(ctx)
+			}
+		}
+	}()
+}
+
+// Stop ends the claim loop and waits for the batch in flight to finish. Entries already claimed are
+// still delivered and recorded, the use case detaches their delivery from the loop context.
+func (d *OutboxDispatcher) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	d.wg.Wait()
+}
+
+// drain keeps dispatching while full batches come back, so a backlog is cleared without waiting
+// for the next tick. No new batch is claimed once the dispatcher is stopped.
+func (d *OutboxDispatcher) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		delivered, err := d.useCase.DispatchOutbox(ctx, d.batchSize)
+		if err != nil {
+			d.logger.ErrorContext(ctx, "outbox dispatch failed", slog.String("error", err.Error()))
+			return
+		}
+		if delivered < d.batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDispatchUseCase answers each DispatchOutbox call with the next delivered count
+type fakeDispatchUseCase struct {
+	delivered []int
+	err       error
+	calls     int
+}
+
+func (f *fakeDispatchUseCase) DispatchOutbox(context.Context, int) (int, error) {
+	f.calls++
+	if f.err != nil {
+		return 0, f.err
+	}
+	return f.delivered[f.calls-1], nil
+}
+
+func TestOutboxDispatcher_Drain(t *testing.T) {
+	tests := []struct {
+		name      string
+		delivered []int
+		err       error
+		wantCalls int
+	}{
+		{name: "full batches are dispatched until the backlog is cleared", delivered: []int{2, 2, 1}, wantCalls: 3},
+		{name: "an idle outbox is dispatched once", delivered: []int{0}, wantCalls: 1},
+		{name: "a failed dispatch waits for the next tick", err: errors.New("database unavailable"), wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase := &fakeDispatchUseCase{delivered: tt.delivered, err: tt.err}
+
+			NewOutboxDispatcher(useCase, 0, 2, logger.Discard()).drain(context.Background())
+
+			assert.Equal(t, tt.wantCalls, useCase.calls)
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config is the service configuration, read from conf/environment and overridden by environment variables
+type Config struct {
+	Server   ServerConfig   `mapstructure:"server"`
+	Database DatabaseConfig `mapstructure:"database"`
+	Auth     AuthConfig     `mapstructure:"auth"`
+	Services ServicesConfig `mapstructure:"services"`
+	Webhooks WebhooksConfig `mapstructure:"webhooks"`
+	Kitchen  KitchenConfig  `mapstructure:"kitchen"`
+	Health   HealthConfig   `mapstructure:"health"`
+	Log      LogConfig      `mapstructure:"log"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
+}
+
+type ServerConfig struct {
+	Port string `mapstructure:"port"`
+	// DrainDelay is how long the service keeps serving while unready after SIGTERM, so the load
+	// balancer stops routing to it before the listener closes
+	DrainDelay time.Duration `mapstructure:"drain_delay"`
+	// ShutdownTimeout is how long in-flight requests may then take to finish
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// RequestTimeout is the deadline of the API requests, cancelling their queries and downstream calls
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	// WebhookTimeout is the shorter deadline of the webhooks, whose callers retry failed deliveries
+	WebhookTimeout time.Duration `mapstructure:"webhook_timeout"`
+}
+
+// DatabaseConfig connects with URL when it is set, otherwise with the individual connection settings
+type DatabaseConfig struct {
+	URL             string        `mapstructure:"url"`
+	Host            string        `mapstructure:"host"`
+	Port            string        `mapstructure:"port"`
+	Name            string        `mapstructure:"name"`
+	User            string        `mapstructure:"user"`
+	Password        string        `mapstructure:"password"`
+	SSLMode         string        `mapstructure:"ssl_mode"`
+	TimeZone        string        `mapstructure:"timezone"`
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+}
+
+const (
+	// AuthModeCentralized leaves the admin accounts to the central auth service
+	AuthModeCentralized = "centralized"
+	// AuthModeLocal stores the admin accounts here and serves their register, login and validate
+	// routes, for stores running without the central auth service
+	AuthModeLocal = "local"
+
+	// SigningMethodHS256 signs the tokens with the shared secret key
+	SigningMethodHS256 = "HS256"
+	// SigningMethodRS256 and SigningMethodES256 sign with the private keys of auth.keys_dir, so other
+	// services verify the tokens with the public keys published in the JWKS
+	SigningMethodRS256 = "RS256"
+	SigningMethodES256 = "ES256"
+)
+
+type AuthConfig struct {
+	// SecretKey signs the tokens with HS256
+	SecretKey string `mapstructure:"secret_key"`
+	// SigningMethod is HS256, RS256 or ES256
+	SigningMethod string `mapstructure:"signing_method"`
+	// KeysDir holds the keys.json manifest and the private keys of the asymmetric signing methods. They
+	// are read on startup, so keys added later are only used after a restart.
+	KeysDir string `mapstructure:"keys_dir"`
+	// Issuer and Audience are set in the issued tokens and required from the validated ones, when
+	// configured. Tokens of the central auth service carry neither.
+	Issuer   string `mapstructure:"issuer"`
+	Audience string `mapstructure:"audience"`
+	// TokenExpiry of the access tokens, kept short since they are only revoked by a denylist
+	TokenExpiry time.Duration `mapstructure:"token_expiry"`
+	// RefreshTokenExpiry of the refresh tokens, which are rotated on every use
+	RefreshTokenExpiry time.Duration `mapstructure:"refresh_token_expiry"`
+	// ServiceURL is the centralized auth service advertised by /auth-info
+	ServiceURL string `mapstructure:"service_url"`
+	// Mode is centralized or local
+	Mode string `mapstructure:"mode"`
+	// DefaultAdminRole is the role of admin tokens carrying none, as the central auth service issues
+	// them. Empty grants those tokens nothing.
+	DefaultAdminRole string `mapstructure:"default_admin_role"`
+	// BootstrapAdmin is created on startup in local mode when it does not exist yet, so there is an
+	// admin to log in and register the others
+	BootstrapAdmin BootstrapAdminConfig `mapstructure:"bootstrap_admin"`
+}
+
+type BootstrapAdminConfig struct {
+	Email    string `mapstructure:"email"`
+	Password string `mapstructure:"password"`
+}
+
+type ServicesConfig struct {
+	Product ServiceConfig `mapstructure:"product"`
+	Payment ServiceConfig `mapstructure:"payment"`
+}
+
+// ServiceConfig is how a downstream service is reached
+type ServiceConfig struct {
+	URL                     string        `mapstructure:"url"`
+	Timeout                 time.Duration `mapstructure:"timeout"`
+	MaxRetries              int           `mapstructure:"max_retries"`
+	BreakerFailureThreshold int           `mapstructure:"breaker_failure_threshold"`
+	BreakerOpenTimeout      time.Duration `mapstructure:"breaker_open_timeout"`
+}
+
+type WebhooksConfig struct {
+	PaymentSecret string `mapstructure:"payment_secret"`
+}
+
+// StaffRoles are the roles of the staff accounts, see the auth entity
+var StaffRoles = []string{"kitchen_staff", "cashier", "manager", "admin"}
+
+// KitchenStations are the stations the order items are prepared on
+var KitchenStations = []string{"grill", "fryer", "drinks", "assembly"}
+
+// KitchenConfig describes the kitchen used to estimate when the orders are ready
+type KitchenConfig struct {
+	// StationCapacity is how many items each station of KitchenStations prepares at the same time
+	StationCapacity map[string]uint `mapstructure:"station_capacity"`
+}
+
+type HealthConfig struct {
+	CheckTimeout time.Duration `mapstructure:"check_timeout"`
+	// RequireDownstream makes the service unready while a downstream service is failing. Otherwise
+	// downstream failures are only reported.
+	RequireDownstream bool `mapstructure:"require_downstream"`
+}
+
+type LogConfig struct {
+	// Level is one of debug, info, warn or error
+	Level string `mapstructure:"level"`
+	// Format is json, for log collectors, or text, for reading locally
+	Format string `mapstructure:"format"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, stdout for looking at spans locally, or otlp to send them to Endpoint
+	Exporter string `mapstructure:"exporter"`
+	// Endpoint is the OTLP/HTTP collector URL, such as http://otel-collector:4318
+	Endpoint    string `mapstructure:"endpoint"`
+	ServiceName string `mapstructure:"service_name"`
+	// SampleRatio is the share of the traces started here that are recorded. Traces started by a
+	// caller follow the caller's decision.
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// envBindings maps each configuration key to the environment variables overriding it, first match wins.
+// The names are the ones already used by the k8s ConfigMap, the Secret and docker-compose.
+var envBindings = map[string][]string{
+	"server.port":             {"PORT", "OPERATION_SERVICE_PORT"},
+	"server.drain_delay":      {"SHUTDOWN_DRAIN_DELAY"},
+	"server.shutdown_timeout": {"SHUTDOWN_TIMEOUT"},
+	"server.request_timeout":  {"REQUEST_TIMEOUT"},
+	"server.webhook_timeout":  {"WEBHOOK_TIMEOUT"},
+
+	"database.url":                {"DATABASE_URL"},
+	"database.host":               {"DB_HOST"},
+	"database.port":               {"DB_PORT"},
+	"database.name":               {"DB_NAME"},
+	"database.user":               {"DB_USER"},
+	"database.password":           {"DB_PASSWORD"},
+	"database.ssl_mode":           {"DB_SSL_MODE"},
+	"database.timezone":           {"DB_TIMEZONE"},
+	"database.max_open_conns":     {"DB_MAX_OPEN_CONNS"},
+	"database.max_idle_conns":     {"DB_MAX_IDLE_CONNS"},
+	"database.conn_max_lifetime":  {"DB_CONN_MAX_LIFETIME"},
+	"database.conn_max_idle_time": {"DB_CONN_MAX_IDLE_TIME"},
+
+	"auth.secret_key":           {"SECRET_KEY"},
+	"auth.signing_method":       {"JWT_SIGNING_METHOD"},
+	"auth.keys_dir":             {"JWT_KEYS_DIR"},
+	"auth.issuer":               {"JWT_ISSUER"},
+	"auth.audience":             {"JWT_AUDIENCE"},
+	"auth.token_expiry":         {"JWT_EXPIRY"},
+	"auth.refresh_token_expiry": {"REFRESH_TOKEN_EXPIRY"},
+	"auth.service_url":          {"AUTH_SERVICE_URL", "CORE_SERVICE_URL"},
+	"auth.mode":                 {"AUTH_MODE"},
+	"auth.default_admin_role":   {"AUTH_DEFAULT_ADMIN_ROLE"},
+
+	"auth.bootstrap_admin.email":    {"ADMIN_BOOTSTRAP_EMAIL"},
+	"auth.bootstrap_admin.password": {"ADMIN_BOOTSTRAP_PASSWORD"},
+
+	// Products are served by the core service
+	"services.product.url":                       {"PRODUCT_SERVICE_URL", "CORE_SERVICE_URL"},
+	"services.product.timeout":                   {"PRODUCT_SERVICE_TIMEOUT"},
+	"services.product.max_retries":               {"PRODUCT_SERVICE_MAX_RETRIES"},
+	"services.product.breaker_failure_threshold": {"PRODUCT_SERVICE_BREAKER_FAILURE_THRESHOLD"},
+	"services.product.breaker_open_timeout":      {"PRODUCT_SERVICE_BREAKER_OPEN_TIMEOUT"},
+	"services.payment.url":                       {"PAYMENT_SERVICE_URL"},
+	"services.payment.timeout":                   {"PAYMENT_SERVICE_TIMEOUT"},
+	"services.payment.max_retries":               {"PAYMENT_SERVICE_MAX_RETRIES"},
+	"services.payment.breaker_failure_threshold": {"PAYMENT_SERVICE_BREAKER_FAILURE_THRESHOLD"},
+	"services.payment.breaker_open_timeout":      {"PAYMENT_SERVICE_BREAKER_OPEN_TIMEOUT"},
+
+	"webhooks.payment_secret": {"PAYMENT_WEBHOOK_SECRET"},
+
+	"health.check_timeout":      {"HEALTH_CHECK_TIMEOUT"},
+	"health.require_downstream": {"HEALTH_REQUIRE_DOWNSTREAM"},
+
+	"log.level":  {"LOG_LEVEL"},
+	"log.format": {"LOG_FORMAT"},
+
+	"tracing.exporter":     {"TRACING_EXPORTER"},
+	"tracing.endpoint":     {"OTEL_EXPORTER_OTLP_ENDPOINT"},
+	"tracing.service_name": {"OTEL_SERVICE_NAME"},
+	"tracing.sample_ratio": {"TRACING_SAMPLE_RATIO"},
+}
+
+// Load reads default.yml from path into the global viper instance, applies the environment
+// overrides and validates the result
+func Load(path string) (Config, error) {
+	viper.SetConfigName("default")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(path)
+
+	if err := viper.ReadInConfig(); err != nil {
+		return Config{}, fmt.Errorf("error reading yaml config: %w", err)
+	}
+
+	for key, envs := range envBindings {
+		if err := viper.BindEnv(append([]string{key}, envs...)...); err != nil {
+			return Config{}, err
+		}
+	}
+
+	var config Config
+	if err := viper.Unmarshal(&config); err != nil {
+		return Config{}, fmt.Errorf("error decoding config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate reports every invalid setting at once
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key string, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	port, err := strconv.Atoi(c.Server.Port)
+	check(err == nil && port > 0 && port < 65536, "server.port", "must be a port number, got %q", c.Server.Port)
+
+	check(c.Server.DrainDelay >= 0, "server.drain_delay", "must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive")
+	check(c.Server.RequestTimeout > 0, "server.request_timeout", "must be positive")
+	check(c.Server.WebhookTimeout > 0, "server.webhook_timeout", "must be positive")
+
+	check(c.Database.URL != "" || c.Database.Host != "", "database", "url or host is required")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns", "must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns", "must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns", "must not exceed max_open_conns")
+
+	check(oneOf(c.Auth.SigningMethod, SigningMethodHS256, SigningMethodRS256, SigningMethodES256), "auth.signing_method",
+		"must be HS256, RS256 or ES256, got %q", c.Auth.SigningMethod)
+	check(c.Auth.SigningMethod != SigningMethodHS256 || c.Auth.SecretKey != "", "auth.secret_key", "is required")
+	check(!oneOf(c.Auth.SigningMethod, SigningMethodRS256, SigningMethodES256) || c.Auth.KeysDir != "", "auth.keys_dir",
+		"is required with %s", c.Auth.SigningMethod)
+	check(c.Auth.TokenExpiry > 0, "auth.token_expiry", "must be positive")
+	check(c.Auth.RefreshTokenExpiry > c.Auth.TokenExpiry, "auth.refresh_token_expiry", "must be longer than auth.token_expiry")
+	check(validURL(c.Auth.ServiceURL), "auth.service_url", "must be an absolute URL, got %q", c.Auth.ServiceURL)
+	check(oneOf(c.Auth.Mode, AuthModeCentralized, AuthModeLocal), "auth.mode",
+		"must be centralized or local, got %q", c.Auth.Mode)
+	check(c.Auth.DefaultAdminRole == "" || oneOf(c.Auth.DefaultAdminRole, StaffRoles...), "auth.default_admin_role",
+		"must be empty or one of %v, got %q", StaffRoles, c.Auth.DefaultAdminRole)
+	// The central auth service signs its tokens with the shared secret key, which only HS256 accepts
+	check(c.Auth.Mode != AuthModeCentralized || c.Auth.SigningMethod == SigningMethodHS256, "auth.signing_method",
+		"must be HS256 with the centralized auth mode, got %q", c.Auth.SigningMethod)
+	check((c.Auth.BootstrapAdmin.Email == "") == (c.Auth.BootstrapAdmin.Password == ""), "auth.bootstrap_admin",
+		"email and password must be set together")
+
+	services := []struct {
+		key     string
+		service ServiceConfig
+	}{
+		{"services.product", c.Services.Product},
+		{"services.payment", c.Services.Payment},
+	}
+	for _, s := range services {
+		key, service := s.key, s.service
+		check(validURL(service.URL), key+".url", "must be an absolute URL, got %q", service.URL)
+		check(service.Timeout > 0, key+".timeout", "must be positive")
+		check(service.MaxRetries >= 0, key+".max_retries", "must not be negative")
+		check(service.BreakerFailureThreshold > 0, key+".breaker_failure_threshold", "must be positive")
+		check(service.BreakerOpenTimeout > 0, key+".breaker_open_timeout", "must be positive")
+	}
+
+	for station, capacity := range c.Kitchen.StationCapacity {
+		key := "kitchen.station_capacity." + station
+		check(oneOf(station, KitchenStations...), key, "unknown station, use one of %v", KitchenStations)
+		check(capacity > 0, key, "must be positive")
+	}
+
+	check(c.Health.CheckTimeout > 0, "health.check_timeout", "must be positive")
+
+	check(oneOf(c.Log.Level, "debug", "info", "warn", "error"), "log.level",
+		"must be debug, info, warn or error, got %q", c.Log.Level)
+	check(oneOf(c.Log.Format, "json", "text"), "log.format", "must be json or text, got %q", c.Log.Format)
+
+	check(oneOf(c.Tracing.Exporter, "none", "stdout", "otlp"), "tracing.exporter",
+		"must be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	check(c.Tracing.Exporter != "otlp" || validURL(c.Tracing.Endpoint), "tracing.endpoint",
+		"must be an absolute URL, got %q", c.Tracing.Endpoint)
+	check(c.Tracing.ServiceName != "", "tracing.service_name", "is required")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1")
+
+	return errors.Join(errs...)
+}
+
+// DSN is the connection string given to the postgres driver
+func (d DatabaseConfig) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
+		d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode, d.TimeZone)
+}
+
+func validURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && parsed.Scheme != "" && parsed.Host != ""
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,22 @@
 package errors
 
+// Codes identify the kind of error in ErrorDTO.Code. Clients can rely on them, unlike the messages.
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodePayloadTooLarge  = "payload_too_large"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeUnavailable      = "service_unavailable"
+	CodeTimeout          = "timeout"
+	CodeCancelled        = "request_cancelled"
+	CodeInternal         = "internal_error"
+)
+
 type ErrorDTO struct {
+	Code         string `json:"code"`
 	Message      string `json:"message"`
 	MessageError string `json:"message_error"`
 }
@@ -21,6 +37,15 @@ func (e *UnauthorizedError) Error() string {
 	return e.Msg
 }
 
+// ForbiddenError is returned when the caller is known but not allowed to do what was asked
+type ForbiddenError struct {
+	Msg string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Msg
+}
+
 type InternalError struct {
 	Msg string
 }
@@ -36,3 +61,32 @@ type NotFoundError struct {
 func (e *NotFoundError) Error() string {
 	return e.Msg
 }
+
+// ConflictError is returned when the request is valid but clashes with the current state of the
+// resource, such as an order status change its current status does not allow
+type ConflictError struct {
+	Msg string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Msg
+}
+
+// UnavailableError is returned when a downstream service failed or is not being called because its
+// circuit is open. Retrying later may succeed.
+type UnavailableError struct {
+	Msg string
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Msg
+}
+
+// TimeoutError is returned when a query or a downstream call did not answer in time
+type TimeoutError struct {
+	Msg string
+}
+
+func (e *TimeoutError) Error() string {
+	return e.Msg
+}
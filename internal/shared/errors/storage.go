@@ -0,0 +1,27 @@
+package errors
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// FromStorage translates a database error into the error the handlers answer with. notFound is the
+// message of a missing record and duplicated the one of a duplicated key, which falls back to the
+// database message when empty.
+func FromStorage(err error, notFound string, duplicated string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &NotFoundError{Msg: notFound}
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		if duplicated == "" {
+			duplicated = err.Error()
+		}
+		return &ConflictError{Msg: duplicated}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &TimeoutError{Msg: err.Error()}
+	default:
+		return &InternalError{Msg: err.Error()}
+	}
+}
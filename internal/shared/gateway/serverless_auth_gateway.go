@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/auth/entity"
 )
 
 // ServerlessAuthGateway implements authentication via AWS Lambda functions
@@ -33,6 +35,9 @@ type CustomClaims struct {
 	UserID   string                 `json:"user_id"`
 	UserType string                 `json:"user_type"`
 	Custom   map[string]interface{} `json:"custom,omitempty"`
+	// Role and permissions of staff users, missing from tokens issued before roles existed
+	Role        entity.Role         `json:"role,omitempty"`
+	Permissions []entity.Permission `json:"permissions,omitempty"`
 	// Standard JWT fields
 	ExpiresAt int64 `json:"exp,omitempty"`
 	IssuedAt  int64 `json:"iat,omitempty"`
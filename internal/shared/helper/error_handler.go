@@ -1,6 +1,8 @@
 package helper
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -8,26 +10,64 @@ import (
 	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
 )
 
+// StatusClientClosedRequest is answered when the client went away before the response was ready. It
+// is never read by the client, but keeps these requests apart from server errors in logs and metrics.
+const StatusClientClosedRequest = 499
+
+// HandleError answers with the status and code of the apperror type found in the err chain.
+// Errors of any other type are internal errors.
 func HandleError(c *gin.Context, err error) {
-	status := http.StatusInternalServerError
-	message := "Internal Server Error"
-
-	switch err.(type) {
-	case *apperror.ValidationError:
-		status = http.StatusBadRequest
-		message = "Validation failed"
-	case *apperror.UnauthorizedError:
-		status = http.StatusUnauthorized
-		message = "Unauthorized"
-	case *apperror.NotFoundError:
-		status = http.StatusBadRequest
-		message = "Invalid resource"
+	status, code, message := classify(err)
+
+	// A cancelled request context explains the failure better than whatever error it caused down the
+	// stack, which is often wrapped into an internal error
+	ctxErr := c.Request.Context().Err()
+	switch {
+	case errors.Is(ctxErr, context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded):
+		status, code, message = http.StatusGatewayTimeout, apperror.CodeTimeout, "Request timed out"
+	case errors.Is(ctxErr, context.Canceled) || errors.Is(err, context.Canceled):
+		status, code, message = StatusClientClosedRequest, apperror.CodeCancelled, "Request cancelled"
 	}
 
+	// Attached so the request logger records the cause
+	_ = c.Error(err)
+
 	c.JSON(status, apperror.ErrorDTO{
+		Code:         code,
 		Message:      message,
 		MessageError: err.Error(),
 	})
 
 	c.Abort()
 }
+
+func classify(err error) (int, string, string) {
+	var (
+		validationErr   *apperror.ValidationError
+		unauthorizedErr *apperror.UnauthorizedError
+		forbiddenErr    *apperror.ForbiddenError
+		notFoundErr     *apperror.NotFoundError
+		conflictErr     *apperror.ConflictError
+		unavailableErr  *apperror.UnavailableError
+		timeoutErr      *apperror.TimeoutError
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest, apperror.CodeValidationFailed, "Validation failed"
+	case errors.As(err, &unauthorizedErr):
+		return http.StatusUnauthorized, apperror.CodeUnauthorized, "Unauthorized"
+	case errors.As(err, &forbiddenErr):
+		return http.StatusForbidden, apperror.CodeForbidden, "Forbidden"
+	case errors.As(err, &notFoundErr):
+		return http.StatusNotFound, apperror.CodeNotFound, "Resource not found"
+	case errors.As(err, &conflictErr):
+		return http.StatusConflict, apperror.CodeConflict, "Conflict"
+	case errors.As(err, &unavailableErr):
+		return http.StatusServiceUnavailable, apperror.CodeUnavailable, "Service unavailable"
+	case errors.As(err, &timeoutErr):
+		return http.StatusGatewayTimeout, apperror.CodeTimeout, "Request timed out"
+	default:
+		return http.StatusInternalServerError, apperror.CodeInternal, "Internal Server Error"
+	}
+}
@@ -0,0 +1,98 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+)
+
+func TestHandleError(t *testing.T) {
+	expired, cancelExpired := context.WithTimeout(context.Background(), 0)
+	defer cancelExpired()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "validation error",
+			ctx:            context.Background(),
+			err:            &apperror.ValidationError{Msg: "invalid status"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   apperror.CodeValidationFailed,
+		},
+		{
+			name:           "not found",
+			ctx:            context.Background(),
+			err:            &apperror.NotFoundError{Msg: "order not found"},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   apperror.CodeNotFound,
+		},
+		{
+			name:           "wrapped conflict",
+			ctx:            context.Background(),
+			err:            fmt.Errorf("cancelling order: %w", &apperror.ConflictError{Msg: "invalid status transition"}),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   apperror.CodeConflict,
+		},
+		{
+			name:           "downstream unavailable",
+			ctx:            context.Background(),
+			err:            &apperror.UnavailableError{Msg: "payment service unavailable: circuit breaker is open"},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   apperror.CodeUnavailable,
+		},
+		{
+			name:           "unknown error",
+			ctx:            context.Background(),
+			err:            errors.New("boom"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   apperror.CodeInternal,
+		},
+		{
+			name:           "request deadline exceeded",
+			ctx:            expired,
+			err:            &apperror.InternalError{Msg: "context deadline exceeded"},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedCode:   apperror.CodeTimeout,
+		},
+		{
+			name:           "client went away",
+			ctx:            cancelled,
+			err:            context.Canceled,
+			expectedStatus: StatusClientClosedRequest,
+			expectedCode:   apperror.CodeCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequestWithContext(tt.ctx, http.MethodGet, "/", nil)
+
+			HandleError(c, tt.err)
+
+			var response apperror.ErrorDTO
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedCode, response.Code)
+			assert.Equal(t, tt.err.Error(), response.MessageError)
+		})
+	}
+}
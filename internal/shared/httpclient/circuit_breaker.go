@@ -0,0 +1,108 @@
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the downstream service while its circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker stops calling a downstream service after failureThreshold consecutive failures.
+// After openTimeout a single trial call is let through: its success closes the circuit again and
+// its failure keeps it open for another openTimeout.
+type CircuitBreaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	now              func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		now:              time.Now,
+	}
+}
+
+// Allow reports whether a call may be made now
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+		b.state = breakerHalfOpen
+		return nil
+	case breakerHalfOpen:
+		// The trial call is still running
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+}
+
+// Failure records a failed call and reports whether it opened the circuit
+func (b *CircuitBreaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == breakerOpen || (b.state == breakerClosed && b.failures < b.failureThreshold) {
+		return false
+	}
+
+	b.state = breakerOpen
+	b.openedAt = b.now()
+	return true
+}
+
+// Abort is called when a call ended without telling whether the service is healthy, such as when
+// the caller gave up. A trial call that is aborted lets the next call be the trial.
+func (b *CircuitBreaker) Abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+// Open reports whether the circuit opened less than openTimeout ago. Once openTimeout elapses the next
+// call is let through as the trial, so the circuit is no longer reported open even when no call was
+// made since, which lets an unready instance become ready again and receive that call.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == breakerOpen && b.now().Sub(b.openedAt) < b.openTimeout
+}
@@ -0,0 +1,312 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/requestid"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxErrorBodySize bounds how much of an error response is read into the error message
+const maxErrorBodySize = 4 << 10
+
+// IdempotencyKeyHeader carries Request.IdempotencyKey, so the service applies a repeated request once
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Config tunes how a Client calls its downstream service
+type Config struct {
+	// Timeout bounds each attempt, including reading the response
+	Timeout time.Duration
+	// MaxRetries is how many times an idempotent request is retried after the first attempt
+	MaxRetries int
+	// RetryBaseDelay is doubled on every retry, up to RetryMaxDelay, and jittered
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// BreakerFailureThreshold consecutive failures open the circuit for BreakerOpenTimeout
+	BreakerFailureThreshold int
+	BreakerOpenTimeout      time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:                 5 * time.Second,
+		MaxRetries:              2,
+		RetryBaseDelay:          100 * time.Millisecond,
+		RetryMaxDelay:           2 * time.Second,
+		BreakerFailureThreshold: 5,
+		BreakerOpenTimeout:      30 * time.Second,
+	}
+}
+
+// Client calls one downstream service with per-attempt timeouts, retries of idempotent requests and
+// a circuit breaker shared by every call to that service. Non-2xx responses are mapped to apperror
+// types and the request id and trace context of the context are forwarded.
+type Client struct {
+	service string
+	baseURL string
+	config  Config
+	http    *http.Client
+	breaker *CircuitBreaker
+	logger  *slog.Logger
+	tracer  trace.Tracer
+}
+
+func NewClient(service string, baseURL string, config Config, logger *slog.Logger) *Client {
+	return &Client{
+		service: service,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		config:  config,
+		http:    &http.Client{},
+		breaker: NewCircuitBreaker(config.BreakerFailureThreshold, config.BreakerOpenTimeout),
+		logger:  logger.With(slog.String("service", service)),
+		tracer:  otel.Tracer(tracing.InstrumentationName),
+	}
+}
+
+// Request describes a call to the downstream service. Body is sent as JSON when it is not nil.
+type Request struct {
+	Method string
+	Path   string
+	Body   any
+	// Idempotent allows retrying methods that are not idempotent by definition, such as a POST search
+	Idempotent bool
+	// IdempotencyKey is sent in IdempotencyKeyHeader when it is set. The service applies every request
+	// with the same key once, so keyed requests are retried like idempotent ones.
+	IdempotencyKey string
+}
+
+var idempotentMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+// Available reports ErrCircuitOpen while recent calls to the service failed, until the open timeout of
+// the circuit breaker elapses. It does not call the service, so it is cheap enough for readiness probes.
+func (c *Client) Available(_ context.Context) error {
+	if c.breaker.Open() {
+		return fmt.Errorf("%s service: %w", c.service, ErrCircuitOpen)
+	}
+	return nil
+}
+
+// Do sends the request and decodes the JSON response into out, unless out is nil
+func (c *Client) Do(ctx context.Context, request Request, out any) error {
+	var payload []byte
+	if request.Body != nil {
+		var err error
+		if payload, err = json.Marshal(request.Body); err != nil {
+			return err
+		}
+	}
+
+	attempts := 1
+	if request.Idempotent || request.IdempotencyKey != "" || idempotentMethods[request.Method] {
+		attempts += c.config.MaxRetries
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			c.logger.WarnContext(ctx, "retrying downstream call",
+				slog.String("method", request.Method),
+				slog.String("path", request.Path),
+				slog.Int("attempt", attempt+1),
+				slog.String("error", err.Error()))
+
+			if waitErr := c.wait(ctx, attempt); waitErr != nil {
+				return err
+			}
+		}
+
+		var retry bool
+		if retry, err = c.attempt(ctx, request, payload, out, attempt); err == nil || !retry {
+			return err
+		}
+	}
+
+	return err
+}
+
+// attempt makes a single call, traced as a client span carrying the trace context to the service,
+// and reports whether a failure may be retried
+func (c *Client) attempt(ctx context.Context, request Request, payload []byte, out any, attempt int) (retry bool, err error) {
+	ctx, span := c.tracer.Start(ctx, fmt.Sprintf("%s %s", c.service, request.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.PeerService(c.service),
+			semconv.HTTPRequestMethodKey.String(request.Method),
+			semconv.URLFull(c.baseURL+request.Path),
+			semconv.HTTPRequestResendCount(attempt),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if err := c.breaker.Allow(); err != nil {
+		downstreamRequestErrors.WithLabelValues(c.service, errorReasonCircuitOpen).Inc()
+		return false, &apperror.UnavailableError{Msg: fmt.Sprintf("%s service unavailable: %v", c.service, err)}
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, request.Method, c.baseURL+request.Path, body)
+	if err != nil {
+		c.breaker.Abort()
+		return false, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if request.IdempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, request.IdempotencyKey)
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		downstreamRequestDuration.WithLabelValues(c.service, request.Method, "error").Observe(time.Since(start).Seconds())
+		if ctx.Err() != nil {
+			downstreamRequestErrors.WithLabelValues(c.service, errorReasonCancelled).Inc()
+			c.breaker.Abort()
+			return false, ctx.Err()
+		}
+		downstreamRequestErrors.WithLabelValues(c.service, errorReasonNetwork).Inc()
+		c.failure(ctx)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return true, &apperror.TimeoutError{Msg: fmt.Sprintf("%s service did not answer within %s", c.service, c.config.Timeout)}
+		}
+		return true, &apperror.UnavailableError{Msg: fmt.Sprintf("%s service request failed: %v", c.service, err)}
+	}
+	defer resp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	defer func() {
+		downstreamRequestDuration.
+			WithLabelValues(c.service, request.Method, strconv.Itoa(resp.StatusCode)).
+			Observe(time.Since(start).Seconds())
+	}()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		c.failure(ctx)
+	} else {
+		c.breaker.Success()
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		downstreamRequestErrors.WithLabelValues(c.service, errorReasonStatus).Inc()
+		return retryableStatus(resp.StatusCode), c.statusError(resp)
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		downstreamRequestErrors.WithLabelValues(c.service, errorReasonDecode).Inc()
+		return false, &apperror.InternalError{Msg: fmt.Sprintf("failed to decode %s service response: %v", c.service, err)}
+	}
+
+	return false, nil
+}
+
+func (c *Client) failure(ctx context.Context) {
+	if c.breaker.Failure() {
+		c.logger.ErrorContext(ctx, "circuit breaker opened, downstream calls are paused",
+			slog.Duration("open_timeout", c.config.BreakerOpenTimeout))
+	}
+}
+
+// wait sleeps before a retry with exponential backoff and jitter, returning early when ctx is done
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	delay := c.config.RetryBaseDelay << (attempt - 1)
+	if delay > c.config.RetryMaxDelay || delay <= 0 {
+		delay = c.config.RetryMaxDelay
+	}
+	if delay > 0 {
+		delay = delay/2 + rand.N(delay/2+1)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// statusError maps a non-2xx response to an apperror, keeping the message of the downstream service.
+// Server errors mean the service is unavailable. A 401 or 403 means this service was not allowed in,
+// which is not the caller's fault, so it is an internal error like any other unexpected status.
+func (c *Client) statusError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+
+	detail := strings.TrimSpace(string(raw))
+	var errorBody apperror.ErrorDTO
+	if json.Unmarshal(raw, &errorBody) == nil && errorBody.Message != "" {
+		detail = errorBody.Message
+		if errorBody.MessageError != "" {
+			detail += ": " + errorBody.MessageError
+		}
+	}
+
+	msg := fmt.Sprintf("%s service returned status %d", c.service, resp.StatusCode)
+	if detail != "" {
+		msg += ": " + detail
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity:
+		return &apperror.ValidationError{Msg: msg}
+	case resp.StatusCode == http.StatusNotFound:
+		return &apperror.NotFoundError{Msg: msg}
+	case resp.StatusCode == http.StatusConflict:
+		return &apperror.ConflictError{Msg: msg}
+	case resp.StatusCode == http.StatusGatewayTimeout:
+		return &apperror.TimeoutError{Msg: msg}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return &apperror.UnavailableError{Msg: msg}
+	default:
+		return &apperror.InternalError{Msg: msg}
+	}
+}
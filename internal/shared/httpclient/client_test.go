@@ -0,0 +1,249 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apperror "github.com/fiap-161/tc-golunch-operation-service/internal/shared/errors"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/logger"
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/requestid"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func testConfig() Config {
+	return Config{
+		Timeout:                 time.Second,
+		MaxRetries:              2,
+		RetryBaseDelay:          time.Millisecond,
+		RetryMaxDelay:           5 * time.Millisecond,
+		BreakerFailureThreshold: 3,
+		BreakerOpenTimeout:      time.Minute,
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	tests := []struct {
+		name         string
+		request      Request
+		statuses     []int
+		wantCalls    int32
+		wantErr      error
+		wantErrValue string
+	}{
+		{
+			name:      "idempotent request is retried until it succeeds",
+			request:   Request{Method: http.MethodPost, Path: "/search", Idempotent: true},
+			statuses:  []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantCalls: 2,
+		},
+		{
+			name:         "non idempotent request is not retried",
+			request:      Request{Method: http.MethodPost, Path: "/payments"},
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantCalls:    1,
+			wantErr:      &apperror.UnavailableError{},
+			wantErrValue: "test service returned status 503: Service down: maintenance",
+		},
+		{
+			name:      "request with an idempotency key is retried",
+			request:   Request{Method: http.MethodPost, Path: "/payments/refund", IdempotencyKey: "refund-1"},
+			statuses:  []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantCalls: 2,
+		},
+		{
+			name:         "not found is mapped and not retried",
+			request:      Request{Method: http.MethodGet, Path: "/products/1"},
+			statuses:     []int{http.StatusNotFound},
+			wantCalls:    1,
+			wantErr:      &apperror.NotFoundError{},
+			wantErrValue: "test service returned status 404: Service down: maintenance",
+		},
+		{
+			name:         "retries stop after max retries",
+			request:      Request{Method: http.MethodGet, Path: "/products"},
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+			wantCalls:    3,
+			wantErr:      &apperror.UnavailableError{},
+			wantErrValue: "test service returned status 502: Service down: maintenance",
+		},
+		{
+			name:         "conflict is mapped and not retried",
+			request:      Request{Method: http.MethodPut, Path: "/orders/1"},
+			statuses:     []int{http.StatusConflict},
+			wantCalls:    1,
+			wantErr:      &apperror.ConflictError{},
+			wantErrValue: "test service returned status 409: Service down: maintenance",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := calls.Add(1)
+				status := tt.statuses[call-1]
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					_, _ = w.Write([]byte(`{"id":"1"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"message":"Service down","message_error":"maintenance"}`))
+			}))
+			defer server.Close()
+
+			var out struct {
+				ID string `json:"id"`
+			}
+			err := NewClient("test", server.URL, testConfig(), logger.Discard()).Do(context.Background(), tt.request, &out)
+
+			assert.Equal(t, tt.wantCalls, calls.Load())
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, "1", out.ID)
+				return
+			}
+			assert.IsType(t, tt.wantErr, err)
+			assert.EqualError(t, err, tt.wantErrValue)
+		})
+	}
+}
+
+func TestClient_PropagatesRequestID(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(requestid.Header)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := requestid.NewContext(context.Background(), "req-123")
+	err := NewClient("test", server.URL, testConfig(), logger.Discard()).Do(ctx, Request{Method: http.MethodGet, Path: "/"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "req-123", received)
+}
+
+func TestPaymentClient_SendsIdempotencyKeys(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.URL.Path+" "+r.Header.Get(IdempotencyKeyHeader))
+		// The first payment attempt is lost on the way back
+		if r.URL.Path == "/payments" && len(received) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"payment-1","qr_code":"qr"}`))
+	}))
+	defer server.Close()
+
+	payments := NewPaymentClient(NewClient("payment", server.URL, testConfig(), logger.Discard()))
+
+	payment, err := payments.CreateByOrderID(context.Background(), "order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "payment-1", payment.ID)
+	assert.NoError(t, payments.RefundByOrderID(context.Background(), "order-1", "customer gave up"))
+
+	assert.Equal(t, []string{
+		"/payments payment-order-1",
+		"/payments payment-order-1",
+		"/payments/refund refund-order-1",
+	}, received)
+}
+
+func TestProductOrderClient_SendsIdempotencyKey(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.Path + " " + r.Header.Get(IdempotencyKeyHeader)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	productOrders := NewProductOrderClient(NewClient("product", server.URL, testConfig(), logger.Discard()))
+
+	assert.NoError(t, productOrders.CreateBulk(context.Background(), "order-1", nil))
+	assert.Equal(t, "/orders/order-1/products product-orders-order-1", received)
+}
+
+func TestClient_PropagatesTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "caller")
+	defer span.End()
+
+	err := NewClient("test", server.URL, testConfig(), logger.Discard()).Do(ctx, Request{Method: http.MethodGet, Path: "/"}, nil)
+
+	assert.NoError(t, err)
+	assert.Contains(t, received, span.SpanContext().TraceID().String())
+}
+
+func TestClient_CircuitBreakerOpens(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient("test", server.URL, testConfig(), logger.Discard())
+	request := Request{Method: http.MethodPost, Path: "/payments"}
+
+	for range 3 {
+		assert.Error(t, client.Do(context.Background(), request, nil))
+	}
+
+	err := client.Do(context.Background(), request, nil)
+	assert.IsType(t, &apperror.UnavailableError{}, err)
+	assert.EqualError(t, err, "test service unavailable: circuit breaker is open")
+	assert.Equal(t, int32(3), calls.Load(), "open circuit does not call the service")
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Failure()
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, breaker.Allow(), "trial call after the open timeout")
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen, "only one trial call at a time")
+
+	breaker.Success()
+	assert.NoError(t, breaker.Allow())
+	assert.False(t, breaker.Open())
+}
+
+func TestClient_AvailableRecoversWithoutTraffic(t *testing.T) {
+	now := time.Now()
+	client := NewClient("test", "http://test", testConfig(), logger.Discard())
+	client.breaker.now = func() time.Time { return now }
+
+	for range testConfig().BreakerFailureThreshold {
+		client.breaker.Failure()
+	}
+	assert.ErrorIs(t, client.Available(context.Background()), ErrCircuitOpen)
+
+	now = now.Add(testConfig().BreakerOpenTimeout)
+	assert.NoError(t, client.Available(context.Background()), "ready for the trial call once the open timeout elapsed")
+
+	// The trial call fails and the circuit opens again
+	assert.NoError(t, client.breaker.Allow())
+	client.breaker.Failure()
+	assert.ErrorIs(t, client.Available(context.Background()), ErrCircuitOpen)
+}
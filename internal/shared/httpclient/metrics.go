@@ -0,0 +1,28 @@
+package httpclient
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reasons a downstream call failed, used as the reason label of downstream_request_errors_total
+const (
+	errorReasonCircuitOpen = "circuit_open"
+	errorReasonCancelled   = "cancelled"
+	errorReasonNetwork     = "network"
+	errorReasonStatus      = "status"
+	errorReasonDecode      = "decode"
+)
+
+var (
+	downstreamRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "downstream_request_duration_seconds",
+		Help:    "Latency of each attempt to call a downstream service, by response status or \"error\".",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service", "method", "status"})
+
+	downstreamRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "downstream_request_errors_total",
+		Help: "Failed attempts to call a downstream service, by reason.",
+	}, []string{"service", "reason"})
+)
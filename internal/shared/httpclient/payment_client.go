@@ -1,52 +1,75 @@
 package httpclient
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/order/entity"
 )
 
 type PaymentClient struct {
-	baseURL string
-	client  *http.Client
+	client *Client
+}
+
+type paymentResponse struct {
+	ID        string     `json:"id"`
+	QRCode    string     `json:"qr_code"`
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
-func NewPaymentClient(baseURL string) *PaymentClient {
+func NewPaymentClient(client *Client) *PaymentClient {
 	return &PaymentClient{
-		baseURL: baseURL,
-		client:  &http.Client{},
+		client: client,
 	}
 }
 
-func (c *PaymentClient) CreateByOrderID(ctx context.Context, orderID string) error {
-	url := fmt.Sprintf("%s/payments", c.baseURL)
-
+// CreateByOrderID creates the payment of an order and returns its id and QR code payload. The order id
+// is the idempotency key, so a retried delivery gets the payment created first instead of a new one.
+func (c *PaymentClient) CreateByOrderID(ctx context.Context, orderID string) (entity.Payment, error) {
 	payload := map[string]string{
 		"order_id": orderID,
 	}
 
-	jsonData, err := json.Marshal(payload)
+	var payment paymentResponse
+	err := c.client.Do(ctx, Request{
+		Method:         http.MethodPost,
+		Path:           "/payments",
+		Body:           payload,
+		IdempotencyKey: "payment-" + orderID,
+	}, &payment)
 	if err != nil {
-		return err
+		return entity.Payment{}, fmt.Errorf("failed to create payment: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
+	if payment.QRCode == "" {
+		return entity.Payment{}, fmt.Errorf("payment service returned no qr code for order %s", orderID)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	return entity.Payment{
+		ID:        payment.ID,
+		QRCode:    payment.QRCode,
+		ExpiresAt: payment.ExpiresAt,
+	}, nil
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return err
+// RefundByOrderID asks the payment service to refund the order payment, or void it when it was not captured yet.
+// The order id is the idempotency key, so a retried cancellation never refunds twice.
+func (c *PaymentClient) RefundByOrderID(ctx context.Context, orderID string, reason string) error {
+	payload := map[string]string{
+		"order_id": orderID,
+		"reason":   reason,
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("failed to create payment: status %d", resp.StatusCode)
+	err := c.client.Do(ctx, Request{
+		Method:         http.MethodPost,
+		Path:           "/payments/refund",
+		Body:           payload,
+		IdempotencyKey: "refund-" + orderID,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to refund payment: %w", err)
 	}
 
 	return nil
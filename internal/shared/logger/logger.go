@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/fiap-161/tc-golunch-operation-service/internal/shared/requestid"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New creates the service logger. Records logged with a context carry its request id and, when the
+// request is traced, its trace and span ids.
+func New(w io.Writer, level string, format string) *slog.Logger {
+	options := &slog.HandlerOptions{Level: ParseLevel(level)}
+
+	var handler slog.Handler = slog.NewJSONHandler(w, options)
+	if format == FormatText {
+		handler = slog.NewTextHandler(w, options)
+	}
+
+	return slog.New(contextHandler{Handler: handler})
+}
+
+// ParseLevel accepts debug, info, warn and error, defaulting to info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Discard is a logger dropping every record, for tests and optional dependencies
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// contextHandler adds the request id and the trace of the record context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,230 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Currency string
+
+const (
+	BRL Currency = "BRL"
+
+	// DefaultCurrency is assumed for amounts that carry no currency, such as prices from the product service
+	DefaultCurrency = BRL
+)
+
+// Money is an exact amount stored in the minor unit (cents) of its currency. It serializes to JSON as a
+// number with two decimals, to the database as a decimal string, and parses both without going through
+// float64, so totals never accumulate rounding errors.
+type Money struct {
+	cents    int64
+	currency Currency
+}
+
+var (
+	ErrCurrencyMismatch = errors.New("money amounts have different currencies")
+	ErrOverflow         = errors.New("money amount is too large")
+)
+
+func New(cents int64, currency Currency) Money {
+	return Money{cents: cents, currency: currency}
+}
+
+// FromCents creates an amount in the default currency
+func FromCents(cents int64) Money {
+	return New(cents, DefaultCurrency)
+}
+
+// Parse reads a decimal amount such as "19.90", "19.9" or "19" in the default currency.
+// Amounts with more than two decimals are rejected instead of rounded.
+func Parse(value string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Money{}, errors.New("empty money amount")
+	}
+
+	// A single sign is allowed, any other one is rejected with the digits below
+	negative := strings.HasPrefix(value, "-")
+	if negative || strings.HasPrefix(value, "+") {
+		value = value[1:]
+	}
+
+	units, fraction, hasFraction := strings.Cut(value, ".")
+	if units == "" && !hasFraction || hasFraction && (fraction == "" || len(fraction) > 2) {
+		return Money{}, fmt.Errorf("invalid money amount %q, use at most two decimals", value)
+	}
+	if units == "" {
+		units = "0"
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	unitsValue, err := strconv.ParseInt(units, 10, 64)
+	if err != nil || strings.ContainsAny(units, "+-") {
+		return Money{}, fmt.Errorf("invalid money amount %q", value)
+	}
+	fractionValue, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil || strings.ContainsAny(fraction, "+-") {
+		return Money{}, fmt.Errorf("invalid money amount %q", value)
+	}
+	if unitsValue > (math.MaxInt64-fractionValue)/100 {
+		return Money{}, fmt.Errorf("money amount %q is too large", value)
+	}
+
+	cents := unitsValue*100 + fractionValue
+	if negative {
+		cents = -cents
+	}
+
+	return FromCents(cents), nil
+}
+
+func (m Money) Cents() int64 {
+	return m.cents
+}
+
+func (m Money) Currency() Currency {
+	if m.currency == "" {
+		return DefaultCurrency
+	}
+	return m.currency
+}
+
+// WithCurrency returns the same amount in the given currency
+func (m Money) WithCurrency(currency Currency) Money {
+	m.currency = currency
+	return m
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency() != other.Currency() {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	sum := m.cents + other.cents
+	if other.cents > 0 && sum < m.cents || other.cents < 0 && sum > m.cents {
+		return Money{}, ErrOverflow
+	}
+	return New(sum, m.Currency()), nil
+}
+
+// Multiply returns ErrOverflow instead of wrapping around when the product does not fit in cents
+func (m Money) Multiply(quantity int64) (Money, error) {
+	product := m.cents * quantity
+	if quantity != 0 && (product/quantity != m.cents || quantity == -1 && m.cents == math.MinInt64) {
+		return Money{}, ErrOverflow
+	}
+	return New(product, m.Currency()), nil
+}
+
+// Compare returns -1, 0 or 1 when m is less than, equal to or greater than other
+func (m Money) Compare(other Money) int {
+	switch {
+	case m.cents < other.cents:
+		return -1
+	case m.cents > other.cents:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (m Money) IsZero() bool {
+	return m.cents == 0
+}
+
+// String formats the amount with two decimals, without the currency, e.g. "59.70"
+func (m Money) String() string {
+	sign := ""
+	cents := m.cents
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding a decimal amount
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(string(data), `"`)
+	if raw == "null" {
+		return nil
+	}
+
+	parsed, err := Parse(raw)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed.WithCurrency(m.Currency())
+	return nil
+}
+
+// UnmarshalParam lets gin bind query and form values into Money
+func (m *Money) UnmarshalParam(param string) error {
+	parsed, err := Parse(param)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m *Money) Scan(src any) error {
+	var raw string
+	switch value := src.(type) {
+	case nil:
+		*m = FromCents(0)
+		return nil
+	case []byte:
+		raw = string(value)
+	case string:
+		raw = value
+	case int64:
+		if value > math.MaxInt64/100 || value < math.MinInt64/100 {
+			return fmt.Errorf("cannot scan %d into money: %w", value, ErrOverflow)
+		}
+		*m = FromCents(value * 100)
+		return nil
+	case float64:
+		raw = strconv.FormatFloat(value, 'f', 2, 64)
+	default:
+		return fmt.Errorf("cannot scan %T into money", src)
+	}
+
+	parsed, err := Parse(trimTrailingZeros(raw))
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// trimTrailingZeros drops insignificant decimals, as numeric columns may be read back as "19.9000"
+func trimTrailingZeros(value string) string {
+	units, fraction, ok := strings.Cut(value, ".")
+	if !ok {
+		return value
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if fraction == "" {
+		return units
+	}
+	return units + "." + fraction
+}
@@ -0,0 +1,110 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantCents int64
+		wantErr   bool
+	}{
+		{name: "two decimals", value: "19.90", wantCents: 1990},
+		{name: "one decimal", value: "19.9", wantCents: 1990},
+		{name: "no decimals", value: "19", wantCents: 1900},
+		{name: "leading dot", value: ".5", wantCents: 50},
+		{name: "negative", value: "-0.01", wantCents: -1},
+		{name: "explicitly positive", value: "+5", wantCents: 500},
+		{name: "two signs", value: "-+5", wantErr: true},
+		{name: "repeated sign", value: "--5", wantErr: true},
+		{name: "sign after plus", value: "+-5", wantErr: true},
+		{name: "too many decimals", value: "19.999", wantErr: true},
+		{name: "trailing dot", value: "19.", wantErr: true},
+		{name: "not a number", value: "abc", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCents, got.Cents())
+		})
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	price, _ := Parse("19.90")
+
+	lineTotal, err := price.Multiply(3)
+	assert.NoError(t, err)
+	total, err := lineTotal.Add(FromCents(10))
+	assert.NoError(t, err)
+	assert.Equal(t, "59.80", total.String())
+
+	_, err = price.Add(New(100, Currency("USD")))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = price.Multiply(math.MaxInt64 / 1000)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = FromCents(math.MinInt64).Multiply(-1)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = FromCents(math.MaxInt64).Add(FromCents(1))
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = FromCents(math.MinInt64).Add(FromCents(-1))
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var product struct {
+		Price Money `json:"price"`
+	}
+
+	for _, body := range []string{`{"price":19.9}`, `{"price":"19.90"}`} {
+		assert.NoError(t, json.Unmarshal([]byte(body), &product))
+		assert.Equal(t, int64(1990), product.Price.Cents())
+	}
+
+	encoded, err := json.Marshal(product)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"price":19.90}`, string(encoded))
+}
+
+func TestMoney_Scan(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       any
+		wantCents int64
+		wantErr   error
+	}{
+		{name: "numeric text", src: []byte("59.70"), wantCents: 5970},
+		{name: "numeric text with padding", src: "59.7000", wantCents: 5970},
+		{name: "integer", src: int64(3), wantCents: 300},
+		{name: "null", src: nil, wantCents: 0},
+		{name: "integer too large for cents", src: int64(math.MaxInt64 / 10), wantErr: ErrOverflow},
+		{name: "integer too small for cents", src: int64(math.MinInt64 / 10), wantErr: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+			err := m.Scan(tt.src)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCents, m.Cents())
+		})
+	}
+}